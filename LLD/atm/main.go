@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"sync"
+	"time"
 )
 
 // --- Account Interface ---
@@ -32,6 +35,31 @@ func (s *SavingsAccount) GetBalance() float64 {
 	return s.balance
 }
 
+// ApplyInterest credits daily-compounded interest for the given number of days,
+// rounded to cents. Zero or negative days is a no-op.
+func (s *SavingsAccount) ApplyInterest(annualRate float64, days int) {
+	if days <= 0 {
+		return
+	}
+	balance := s.balance * math.Pow(1+annualRate/365, float64(days))
+	s.balance = math.Round(balance*100) / 100
+}
+
+// --- Interest Maintenance ---
+type InterestBearing interface {
+	ApplyInterest(annualRate float64, days int)
+}
+
+// AccrueInterest is the maintenance operation crediting interest
+// to every account that earns it.
+func AccrueInterest(accounts []Account, annualRate float64, days int) {
+	for _, account := range accounts {
+		if ib, ok := account.(InterestBearing); ok {
+			ib.ApplyInterest(annualRate, days)
+		}
+	}
+}
+
 // --- Account Factory ---
 type AccountFactory struct{}
 
@@ -44,6 +72,56 @@ func (f *AccountFactory) CreateAccount(accountType string, initialBalance float6
 	}
 }
 
+// --- Card ---
+const (
+	maxPinAttempts = 3
+	bankAdminPin   = "0000" // admin PIN the bank uses to unlock cards
+)
+
+// Card tracks its own failed PIN attempts, so a lockout survives
+// ejecting and re-inserting the card.
+type Card struct {
+	Account        Account
+	pin            int
+	failedAttempts int
+	locked         bool
+}
+
+func NewCard(account Account, pin int) *Card {
+	return &Card{Account: account, pin: pin}
+}
+
+func (c *Card) IsLocked() bool {
+	return c.locked
+}
+
+// ValidatePin checks the PIN and locks the card after maxPinAttempts failures.
+func (c *Card) ValidatePin(pin int) error {
+	if c.locked {
+		return fmt.Errorf("card is locked")
+	}
+	if pin != c.pin {
+		c.failedAttempts++
+		if c.failedAttempts >= maxPinAttempts {
+			c.locked = true
+			return fmt.Errorf("incorrect PIN, card is locked")
+		}
+		return fmt.Errorf("incorrect PIN, %d attempts left", maxPinAttempts-c.failedAttempts)
+	}
+	c.failedAttempts = 0
+	return nil
+}
+
+// UnlockCard clears the lockout, it needs the bank admin PIN.
+func (c *Card) UnlockCard(adminPin string) error {
+	if adminPin != bankAdminPin {
+		return fmt.Errorf("invalid admin PIN")
+	}
+	c.locked = false
+	c.failedAttempts = 0
+	return nil
+}
+
 // --- Strategy Pattern for Transactions ---
 type TransactionStrategy interface {
 	Execute(account Account, amount float64) error
@@ -64,19 +142,19 @@ func (d *DepositStrategy) Execute(account Account, amount float64) error {
 
 // --- State Pattern for ATM ---
 type ATMState interface {
-	InsertCard(atm *ATM, account Account)
+	InsertCard(atm *ATM, card *Card)
 	EjectCard(atm *ATM)
 	EnterPin(atm *ATM, pin int)
-	RequestTransaction(account Account, requestType string, amount float64)
+	RequestTransaction(account Account, requestType string, amount float64) (TransactionResult, error)
 }
 
 // Idle State
 type IdleState struct{}
 
-func (i *IdleState) InsertCard(atm *ATM, account Account) {
+func (i *IdleState) InsertCard(atm *ATM, card *Card) {
 	fmt.Println("Card Inserted. Please enter PIN.")
 	atm.SetState(&HasCardState{
-		Account: account,
+		Card: card,
 	})
 }
 func (i *IdleState) EjectCard(atm *ATM) {
@@ -85,26 +163,34 @@ func (i *IdleState) EjectCard(atm *ATM) {
 func (i *IdleState) EnterPin(atm *ATM, pin int) {
 	fmt.Println("Insert card first.")
 }
-func (i *IdleState) RequestTransaction(account Account, requestType string, amount float64) {
-	fmt.Println("Insert card first.")
+func (i *IdleState) RequestTransaction(account Account, requestType string, amount float64) (TransactionResult, error) {
+	return TransactionResult{}, fmt.Errorf("insert card first")
 }
 
 // Has Card State
 type HasCardState struct {
-	Account Account
+	Card *Card
 }
 
-func (h *HasCardState) InsertCard(atm *ATM, account Account) {
+func (h *HasCardState) InsertCard(atm *ATM, card *Card) {
 	fmt.Println("Card already inserted.")
 }
 func (h *HasCardState) EjectCard(atm *ATM) {
 	fmt.Println("Card Ejected.")
+	atm.SetState(&IdleState{})
 }
 func (h *HasCardState) EnterPin(atm *ATM, pin int) {
+	if err := h.Card.ValidatePin(pin); err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println("PIN accepted. You may proceed with a transaction.")
+	atm.SetState(&PinEnteredState{
+		atmProcessFactory: &AtmProcessFactory{},
+	})
 }
-func (h *HasCardState) RequestTransaction(account Account, requestType string, amount float64) {
-	fmt.Println("Enter PIN first.")
+func (h *HasCardState) RequestTransaction(account Account, requestType string, amount float64) (TransactionResult, error) {
+	return TransactionResult{}, fmt.Errorf("enter PIN first")
 }
 
 // Pin Entered State
@@ -131,37 +217,50 @@ func (f *AtmProcessFactory) CreateProcess(requestType string, amount float64) IA
 	return nil
 }
 
+// TransactionResult is what a completed transaction hands back to the caller
+type TransactionResult struct {
+	Type    string
+	Amount  float64
+	Balance float64
+}
+
 type IAtmProcessExecute interface {
-	Execute(account Account) error
+	Execute(account Account) (TransactionResult, error)
 }
 
 type WithdrawProcess struct {
 	amount float64
 }
 
-func (w *WithdrawProcess) Execute(account Account) error {
-	return nil
+func (w *WithdrawProcess) Execute(account Account) (TransactionResult, error) {
+	if err := (&WithdrawStrategy{}).Execute(account, w.amount); err != nil {
+		return TransactionResult{}, err
+	}
+	return TransactionResult{Type: "withdraw", Amount: w.amount, Balance: account.GetBalance()}, nil
 }
 
 type DepositProcess struct {
 	amount float64
 }
 
-func (d *DepositProcess) Execute(account Account) error {
-	return nil
+func (d *DepositProcess) Execute(account Account) (TransactionResult, error) {
+	if err := (&DepositStrategy{}).Execute(account, d.amount); err != nil {
+		return TransactionResult{}, err
+	}
+	return TransactionResult{Type: "deposit", Amount: d.amount, Balance: account.GetBalance()}, nil
 }
 
 type CheckBalanceProcess struct{}
 
-func (c *CheckBalanceProcess) Execute(account Account) error {
-	return nil
+func (c *CheckBalanceProcess) Execute(account Account) (TransactionResult, error) {
+	return TransactionResult{Type: "check balance", Balance: account.GetBalance()}, nil
 }
 
 type PinEnteredState struct {
 	atmProcessFactory IAtmProcessFactory
 }
 
-func (p *PinEnteredState) InsertCard(atm *ATM, account Account) {
+func (p *PinEnteredState) InsertCard(atm *ATM, card *Card) {
 	fmt.Println("Card already inserted.")
 }
 func (p *PinEnteredState) EjectCard(atm *ATM) {
@@ -171,43 +270,222 @@ func (p *PinEnteredState) EjectCard(atm *ATM) {
 func (p *PinEnteredState) EnterPin(atm *ATM, pin int) {
 	fmt.Println("PIN already entered.")
 }
-func (p *PinEnteredState) RequestTransaction(account Account, requestType string, amount float64) {
+func (p *PinEnteredState) RequestTransaction(account Account, requestType string, amount float64) (TransactionResult, error) {
 	process := p.atmProcessFactory.CreateProcess(requestType, amount)
-	process.Execute(account)
+	if process == nil {
+		return TransactionResult{}, fmt.Errorf("unsupported transaction %q", requestType)
+	}
+	return process.Execute(account)
+}
+
+// --- Audit Trail ---
+const (
+	AuditTransition = "transition"
+	AuditPin        = "enter pin"
+	AuditTransact   = "transaction"
+)
+
+// AuditEntry is one record of the ATM audit trail. Transitions fill From and To,
+// PIN and transaction attempts fill Success and Err, transactions also the
+// account, type and amount.
+type AuditEntry struct {
+	Timestamp       time.Time
+	Event           string
+	From, To        string
+	Account         Account
+	TransactionType string
+	Amount          float64
+	Success         bool
+	Err             string
+}
+
+// stateName returns the name of the state used in the audit trail
+func stateName(state ATMState) string {
+	switch state.(type) {
+	case *IdleState:
+		return "Idle"
+	case *HasCardState:
+		return "HasCard"
+	case *PinEnteredState:
+		return "PinEntered"
+	}
+	return fmt.Sprintf("%T", state)
+}
+
+// audit appends to the audit trail, callers hold a.mu
+func (a *ATM) audit(entry AuditEntry) {
+	entry.Timestamp = time.Now()
+	a.auditLog = append(a.auditLog, entry)
+}
+
+// AuditLog returns the audit trail, oldest entry first
+func (a *ATM) AuditLog() []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]AuditEntry(nil), a.auditLog...)
+}
+
+// --- Idle Timeout ---
+type IdleTimer interface {
+	Stop() bool
+}
+
+// AfterFunc schedules f after d, time.AfterFunc by default, tests can inject their own
+type AfterFunc func(d time.Duration, f func()) IdleTimer
+
+func realAfterFunc(d time.Duration, f func()) IdleTimer {
+	return time.AfterFunc(d, f)
 }
 
 // ATM Context
 type ATM struct {
-	state ATMState
+	state       ATMState
+	mu          sync.Mutex
+	idleTimeout time.Duration // zero never times out
+	afterFunc   AfterFunc
+	idleTimer   IdleTimer
+	idleGen     int // bumped on every interaction, stale timers see a different value
+	auditLog    []AuditEntry
+}
+
+// NewATM creates an idle ATM that ejects the card after idleTimeout without interaction
+func NewATM(idleTimeout time.Duration) *ATM {
+	return NewATMWithTimer(idleTimeout, realAfterFunc)
+}
+
+func NewATMWithTimer(idleTimeout time.Duration, afterFunc AfterFunc) *ATM {
+	return &ATM{state: &IdleState{}, idleTimeout: idleTimeout, afterFunc: afterFunc}
+}
+
+// touch restarts the inactivity timer, callers hold a.mu
+func (a *ATM) touch() {
+	a.idleGen++
+	if a.idleTimer != nil {
+		a.idleTimer.Stop()
+		a.idleTimer = nil
+	}
+	if _, idle := a.state.(*IdleState); idle || a.idleTimeout <= 0 || a.afterFunc == nil {
+		return
+	}
+	gen := a.idleGen
+	a.idleTimer = a.afterFunc(a.idleTimeout, func() { a.timeout(gen) })
+}
+
+// timeout ejects the card unless there was an interaction since the timer was set
+func (a *ATM) timeout(gen int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if gen != a.idleGen {
+		return
+	}
+	a.idleTimer = nil
+	fmt.Println("Session timed out.")
+	a.state.EjectCard(a)
 }
 
 func (a *ATM) SetState(state ATMState) {
+	a.audit(AuditEntry{Event: AuditTransition, From: stateName(a.state), To: stateName(state), Success: true})
 	a.state = state
 }
-func (a *ATM) InsertCard(account Account) {
-	a.state.InsertCard(a, account)
-	a.state = &HasCardState{Account: account}
+func (a *ATM) InsertCard(card *Card) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.state.InsertCard(a, card)
+	a.touch()
 }
 func (a *ATM) EjectCard() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.state.EjectCard(a)
-	a.state = &IdleState{}
+	a.touch()
 }
 func (a *ATM) EnterPin(pin int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hasCard, ok := a.state.(*HasCardState)
 	a.state.EnterPin(a, pin)
-	a.state = &PinEnteredState{}
+	if ok {
+		_, accepted := a.state.(*PinEnteredState)
+		entry := AuditEntry{Event: AuditPin, Account: hasCard.Card.Account, Success: accepted}
+		if !accepted {
+			entry.Err = "incorrect PIN"
+			if hasCard.Card.IsLocked() {
+				entry.Err = "card is locked"
+			}
+		}
+		a.audit(entry)
+	}
+	a.touch()
+}
+func (a *ATM) RequestTransaction(account Account, requestType string, amount float64) (TransactionResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.touch()
+	result, err := a.state.RequestTransaction(account, requestType, amount)
+	entry := AuditEntry{Event: AuditTransact, Account: account, TransactionType: requestType, Amount: amount, Success: err == nil}
+	if err != nil {
+		entry.Err = err.Error()
+	}
+	a.audit(entry)
+	return result, err
 }
-func (a *ATM) RequestTransaction(account Account, requestType string, amount float64) {
-	a.state.RequestTransaction(account, requestType, amount)
-	a.state = &HasCardState{}
+
+// IsIdle reports whether the ATM is waiting for a card
+func (a *ATM) IsIdle() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, idle := a.state.(*IdleState)
+	return idle
 }
 
 func main() {
 	factory := &AccountFactory{}
 	account := factory.CreateAccount("savings", 1000)
-	atm := &ATM{state: &IdleState{}}
+	card := NewCard(account, 1234)
+	atm := NewATM(0)
+
+	atm.InsertCard(card)
+	atm.EnterPin(4321)
+	atm.EnterPin(1234)
+	if _, err := atm.RequestTransaction(account, "withdraw", 500); err != nil {
+		fmt.Println(err)
+	}
+	if result, err := atm.RequestTransaction(account, "check balance", 0); err == nil {
+		fmt.Printf("Balance: %.2f\n", result.Balance)
+	}
+	atm.EjectCard()
+	for _, entry := range atm.AuditLog() {
+		switch entry.Event {
+		case AuditTransition:
+			fmt.Printf("Audit: %s -> %s\n", entry.From, entry.To)
+		case AuditPin:
+			fmt.Printf("Audit: PIN success=%t %s\n", entry.Success, entry.Err)
+		default:
+			fmt.Printf("Audit: %s %.2f success=%t %s\n", entry.TransactionType, entry.Amount, entry.Success, entry.Err)
+		}
+	}
 
-	atm.InsertCard(account)
+	// Three wrong PINs lock the card, even across re-insertion
+	atm.InsertCard(card)
+	for i := 0; i < maxPinAttempts; i++ {
+		atm.EnterPin(1111)
+	}
+	atm.EjectCard()
+	atm.InsertCard(card)
 	atm.EnterPin(1234)
-	atm.RequestTransaction(account, "withdraw", 500)
 	atm.EjectCard()
+	if err := card.UnlockCard(bankAdminPin); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("Card locked after unlock:", card.IsLocked())
+
+	// Walking away after inserting the card returns the ATM to idle
+	timed := NewATM(50 * time.Millisecond)
+	timed.InsertCard(card)
+	time.Sleep(100 * time.Millisecond)
+	fmt.Println("Idle after timeout:", timed.IsIdle())
+
+	AccrueInterest([]Account{account}, 0.05, 30)
+	fmt.Printf("Balance after 30 days of interest: %.2f\n", account.GetBalance())
 }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApplyInterestThirtyDays(t *testing.T) {
+	account := &SavingsAccount{balance: 1000}
+	account.ApplyInterest(0.05, 30)
+	// 1000 * (1 + 0.05/365)^30
+	if got := account.GetBalance(); math.Abs(got-1004.12) > 0.005 {
+		t.Fatalf("balance = %.4f, want 1004.12", got)
+	}
+
+	account.ApplyInterest(0.05, 0)
+	account.ApplyInterest(0.05, -3)
+	if got := account.GetBalance(); got != 1004.12 {
+		t.Fatalf("balance = %.4f after no-op accruals, want 1004.12", got)
+	}
+}
+
+func TestLockoutSurvivesReinsertion(t *testing.T) {
+	account := &SavingsAccount{balance: 100}
+	card := NewCard(account, 1234)
+	atm := NewATM(0)
+
+	atm.InsertCard(card)
+	for i := 0; i < maxPinAttempts; i++ {
+		atm.EnterPin(1111)
+	}
+	if !card.IsLocked() {
+		t.Fatal("card not locked after three wrong PINs")
+	}
+	atm.EjectCard()
+	atm.InsertCard(card)
+	atm.EnterPin(1234)
+	if _, err := atm.RequestTransaction(account, "check balance", 0); err == nil {
+		t.Fatal("locked card reached a transaction")
+	}
+
+	if err := card.UnlockCard("9999"); err == nil || !card.IsLocked() {
+		t.Fatal("unlocked with a wrong admin PIN")
+	}
+	if err := card.UnlockCard(bankAdminPin); err != nil {
+		t.Fatal(err)
+	}
+	atm.EnterPin(1234)
+	if _, err := atm.RequestTransaction(account, "check balance", 0); err != nil {
+		t.Fatalf("after unlocking: %v", err)
+	}
+}
+
+func TestCheckBalanceReturnsBalance(t *testing.T) {
+	account := (&AccountFactory{}).CreateAccount("savings", 750)
+	atm := NewATM(0)
+	atm.InsertCard(NewCard(account, 1234))
+	atm.EnterPin(1234)
+
+	result, err := atm.RequestTransaction(account, "check balance", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Balance != 750 || result.Type != "check balance" {
+		t.Fatalf("result = %+v, want a balance of 750", result)
+	}
+	result, err = atm.RequestTransaction(account, "withdraw", 200)
+	if err != nil || result.Balance != 550 || result.Amount != 200 {
+		t.Fatalf("withdraw = %+v, %v, want 200 taken leaving 550", result, err)
+	}
+	if _, err := atm.RequestTransaction(account, "transfer", 10); err == nil {
+		t.Fatal("unsupported transaction succeeded")
+	}
+}
+
+// fakeTimers hands out timers that only fire when the test says so
+type fakeTimers struct {
+	pending []*fakeTimer
+}
+
+type fakeTimer struct {
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	wasPending := !t.stopped
+	t.stopped = true
+	return wasPending
+}
+
+func (ft *fakeTimers) AfterFunc(d time.Duration, f func()) IdleTimer {
+	timer := &fakeTimer{f: f}
+	ft.pending = append(ft.pending, timer)
+	return timer
+}
+
+// fire runs the timers that weren't stopped, as if the timeout passed
+func (ft *fakeTimers) fire() {
+	pending := ft.pending
+	ft.pending = nil
+	for _, timer := range pending {
+		if !timer.stopped {
+			timer.stopped = true
+			timer.f()
+		}
+	}
+}
+
+func TestIdleTimeoutEjectsCard(t *testing.T) {
+	timers := &fakeTimers{}
+	atm := NewATMWithTimer(time.Minute, timers.AfterFunc)
+	account := &SavingsAccount{balance: 100}
+
+	atm.InsertCard(NewCard(account, 1234))
+	atm.EnterPin(1234)
+	if atm.IsIdle() {
+		t.Fatal("idle right after entering the PIN")
+	}
+	timers.fire()
+	if !atm.IsIdle() {
+		t.Fatal("not idle after the timeout")
+	}
+	if _, err := atm.RequestTransaction(account, "check balance", 0); err == nil {
+		t.Fatal("transaction accepted after the card was ejected")
+	}
+}
+
+func TestInteractionRestartsIdleTimer(t *testing.T) {
+	timers := &fakeTimers{}
+	atm := NewATMWithTimer(time.Minute, timers.AfterFunc)
+	atm.InsertCard(NewCard(&SavingsAccount{}, 1234))
+	first := timers.pending[0]
+
+	atm.EnterPin(1234)
+	if !first.stopped {
+		t.Fatal("timer from the insert still running after entering the PIN")
+	}
+	atm.EjectCard()
+	timers.fire()
+	if !atm.IsIdle() || len(timers.pending) != 0 {
+		t.Fatal("an idle ATM should have no timer running")
+	}
+}
+
+func TestAuditTrailInOrder(t *testing.T) {
+	account := &SavingsAccount{balance: 100}
+	atm := NewATM(0)
+	atm.InsertCard(NewCard(account, 1234))
+	atm.EnterPin(4321)
+	atm.EnterPin(1234)
+	atm.RequestTransaction(account, "withdraw", 40)
+	atm.RequestTransaction(account, "withdraw", 500)
+
+	var got []string
+	for _, entry := range atm.AuditLog() {
+		if entry.Timestamp.IsZero() {
+			t.Fatalf("entry %+v has no timestamp", entry)
+		}
+		switch entry.Event {
+		case AuditTransition:
+			got = append(got, entry.From+"->"+entry.To)
+		case AuditPin:
+			got = append(got, fmt.Sprintf("pin %t", entry.Success))
+		case AuditTransact:
+			if entry.Account != account {
+				t.Fatalf("transaction entry %+v for another account", entry)
+			}
+			got = append(got, fmt.Sprintf("%s %.0f %t %s", entry.TransactionType, entry.Amount, entry.Success, entry.Err))
+		}
+	}
+	want := []string{
+		"Idle->HasCard",
+		"pin false",
+		"HasCard->PinEntered",
+		"pin true",
+		"withdraw 40 true ",
+		"withdraw 500 false insufficient funds",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("audit log:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAuditLogConcurrentAccess(t *testing.T) {
+	account := &SavingsAccount{balance: 1000}
+	atm := NewATM(0)
+	atm.InsertCard(NewCard(account, 1234))
+	atm.EnterPin(1234)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			atm.RequestTransaction(account, "deposit", 1)
+		}()
+		go func() {
+			defer wg.Done()
+			atm.AuditLog()
+		}()
+	}
+	wg.Wait()
+	if n := len(atm.AuditLog()); n != 13 {
+		t.Fatalf("%d audit entries, want 13", n)
+	}
+}
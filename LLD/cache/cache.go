@@ -3,6 +3,8 @@ package main
 import (
 	"container/list"
 	"fmt"
+	"sort"
+	"sync"
 )
 
 type EvictionStrategy interface {
@@ -14,8 +16,83 @@ type LRUEviction struct{}
 
 func (l *LRUEviction) Evict(cache *LRUCache) {
 	if el := cache.evictionList.Back(); el != nil {
-		cache.evictionList.Remove(el)
-		delete(cache.data, el.Value.(*Entry).key)
+		cache.remove(el)
+	}
+}
+
+// FIFOEviction evicts the oldest inserted entry, no matter how recently it was read
+type FIFOEviction struct{}
+
+func (f *FIFOEviction) Evict(cache *LRUCache) {
+	if first := cache.insertionList.Front(); first != nil {
+		cache.remove(cache.data[first.Value.(string)])
+	}
+}
+
+// PriorityEviction evicts the entry with the lowest priority, the least
+// recently used one among equals
+type PriorityEviction struct{}
+
+func (p *PriorityEviction) Evict(cache *LRUCache) {
+	var victim *list.Element
+	for el := cache.evictionList.Back(); el != nil; el = el.Prev() {
+		if victim == nil || el.Value.(*Entry).priority < victim.Value.(*Entry).priority {
+			victim = el
+		}
+	}
+	if victim != nil {
+		cache.remove(victim)
+	}
+}
+
+// AccessHistoryStrategy is implemented by strategies that need the
+// last N access times of every entry
+type AccessHistoryStrategy interface {
+	HistoryDepth() int
+}
+
+// LRUKEviction evicts the entry whose K-th most recent access is the oldest.
+// Entries accessed fewer than K times go first, oldest last access first,
+// so a one-time scan can't push out keys that are used repeatedly.
+type LRUKEviction struct {
+	K int
+}
+
+// NewLRUKEviction creates an LRU-K strategy, K must be at least 1
+func NewLRUKEviction(k int) (*LRUKEviction, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("LRU-K needs K >= 1, got %d", k)
+	}
+	return &LRUKEviction{K: k}, nil
+}
+
+// k returns K, a zero or negative K set on the struct directly acts as 1
+func (l *LRUKEviction) k() int {
+	return max(l.K, 1)
+}
+
+func (l *LRUKEviction) HistoryDepth() int {
+	return l.k()
+}
+
+func (l *LRUKEviction) Evict(cache *LRUCache) {
+	k := l.k()
+	var victim *list.Element
+	var victimScore, victimLast int64
+	for el := cache.evictionList.Back(); el != nil; el = el.Prev() {
+		entry := el.Value.(*Entry)
+		// backward K-distance, -1 stands for infinite
+		score := int64(-1)
+		if len(entry.history) >= k {
+			score = entry.history[len(entry.history)-k]
+		}
+		last := entry.history[len(entry.history)-1]
+		if victim == nil || score < victimScore || (score == victimScore && last < victimLast) {
+			victim, victimScore, victimLast = el, score, last
+		}
+	}
+	if victim != nil {
+		cache.remove(victim)
 	}
 }
 
@@ -30,51 +107,223 @@ type Cache interface {
 type LRUCache struct {
 	capacity         int
 	data             map[string]*list.Element
-	evictionList     *list.List
+	evictionList     *list.List // most recently used first
+	insertionList    *list.List // keys, oldest inserted first
 	evictionStrategy EvictionStrategy
+	clock            int64 // logical time, ticks on every access
+	historyDepth     int   // access times kept per entry
+	mu               sync.Mutex
 }
 
 // Entry represents a key-value pair in the cache
 type Entry struct {
-	key   string
-	value interface{}
+	key      string
+	value    interface{}
+	history  []int64 // most recent access last
+	inserted *list.Element
+	priority int // set by PutWithPriority, 0 otherwise
 }
 
 // Constructor for LRUCache
 func NewLRUCache(capacity int) *LRUCache {
 	return &LRUCache{
-		capacity:     capacity,
-		data:         make(map[string]*list.Element),
-		evictionList: list.New(),
+		capacity:         capacity,
+		data:             make(map[string]*list.Element),
+		evictionList:     list.New(),
+		insertionList:    list.New(),
+		evictionStrategy: &LRUEviction{},
+		historyDepth:     1,
 	}
 }
 
-// Put adds an item to the cache
+// recordAccess appends the current logical time to the entry history
+func (c *LRUCache) recordAccess(entry *Entry) {
+	c.clock++
+	entry.history = append(entry.history, c.clock)
+	if len(entry.history) > c.historyDepth {
+		entry.history = entry.history[len(entry.history)-c.historyDepth:]
+	}
+}
+
+// remove drops the entry held by el from the cache
+func (c *LRUCache) remove(el *list.Element) {
+	entry := el.Value.(*Entry)
+	c.evictionList.Remove(el)
+	c.insertionList.Remove(entry.inserted)
+	delete(c.data, entry.key)
+}
+
+// Put adds an item to the cache, an existing key keeps its priority
 func (c *LRUCache) Put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(key, value, 0, false)
+}
+
+// PutWithPriority adds an item with the priority used by PriorityEviction,
+// higher priorities are evicted last
+func (c *LRUCache) PutWithPriority(key string, value interface{}, priority int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(key, value, priority, true)
+}
+
+// put adds or updates the item, callers hold c.mu
+func (c *LRUCache) put(key string, value interface{}, priority int, setPriority bool) {
 	if el, ok := c.data[key]; ok {
 		c.evictionList.MoveToFront(el)
-		el.Value.(*Entry).value = value
+		entry := el.Value.(*Entry)
+		entry.value = value
+		if setPriority {
+			entry.priority = priority
+		}
+		c.recordAccess(entry)
 		return
 	}
 	if len(c.data) >= c.capacity {
 		c.evictionStrategy.Evict(c)
 	}
-	el := c.evictionList.PushFront(&Entry{key, value})
+	entry := &Entry{key: key, value: value, inserted: c.insertionList.PushBack(key), priority: priority}
+	c.recordAccess(entry)
+	el := c.evictionList.PushFront(entry)
 	c.data[key] = el
 }
 
+// CacheItem is a key-value pair handed to Warm
+type CacheItem struct {
+	Key   string
+	Value interface{}
+}
+
+// Warm preloads the entries under a single lock, eg. at startup. The entries
+// are ordered oldest first, as if they were Put one by one. Only the most
+// recent keys that fit in the capacity are loaded, so warming never evicts
+// its own entries, older entries make room as needed.
+func (c *LRUCache) Warm(entries []CacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// walk back from the most recent entry until the capacity is used up
+	start, seen := len(entries), make(map[string]bool)
+	for start > 0 {
+		key := entries[start-1].Key
+		if !seen[key] && len(seen) >= c.capacity {
+			break
+		}
+		seen[key] = true
+		start--
+	}
+	for _, item := range entries[start:] {
+		c.put(item.Key, item.Value, 0, false)
+	}
+}
+
 // Get retrieves an item from the cache
 func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if el, ok := c.data[key]; ok {
 		c.evictionList.MoveToFront(el)
+		c.recordAccess(el.Value.(*Entry))
 		return el.Value.(*Entry).value, true
 	}
 	return nil, false
 }
 
+// GetMultiple retrieves the present keys under a single lock, every hit counts
+// as an access in the order of keys
+func (c *LRUCache) GetMultiple(keys []string) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if el, ok := c.data[key]; ok {
+			c.evictionList.MoveToFront(el)
+			c.recordAccess(el.Value.(*Entry))
+			found[key] = el.Value.(*Entry).value
+		}
+	}
+	return found
+}
+
+// PutMultiple adds the items in key order under a single lock, like
+// calling Put for each of them
+func (c *LRUCache) PutMultiple(items map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.put(key, items[key], 0, false)
+	}
+}
+
 // SetEvictionStrategy sets the eviction strategy for the cache
 func (c *LRUCache) SetEvictionStrategy(strategy EvictionStrategy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.evictionStrategy = strategy
+	c.historyDepth = 1
+	if hs, ok := strategy.(AccessHistoryStrategy); ok && hs.HistoryDepth() > 1 {
+		c.historyDepth = hs.HistoryDepth()
+	}
+}
+
+// Resize changes the capacity, evicting entries right away when the cache
+// holds more than the new capacity. Growing never evicts.
+func (c *LRUCache) Resize(newCapacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacity = newCapacity
+	for len(c.data) > c.capacity && len(c.data) > 0 {
+		c.evictionStrategy.Evict(c)
+	}
+}
+
+// ============================ Generic Wrapper (Typed Cache) ============================
+
+// TypedCache wraps an LRUCache so callers get values back without type assertions.
+// Eviction is left to the underlying cache.
+type TypedCache[V any] struct {
+	cache *LRUCache
+}
+
+func NewTypedCache[V any](capacity int) *TypedCache[V] {
+	return &TypedCache[V]{cache: NewLRUCache(capacity)}
+}
+
+// Put adds an item to the cache
+func (c *TypedCache[V]) Put(key string, value V) {
+	c.cache.Put(key, value)
+}
+
+// Get retrieves an item from the cache, the zero value when it's missing
+func (c *TypedCache[V]) Get(key string) (V, bool) {
+	var zero V
+	value, ok := c.cache.Get(key)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(V)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+// SetEvictionStrategy sets the eviction strategy of the underlying cache
+func (c *TypedCache[V]) SetEvictionStrategy(strategy EvictionStrategy) {
+	c.cache.SetEvictionStrategy(strategy)
 }
 
 // ============================ Factory Pattern (Cache Factory) ============================
@@ -135,4 +384,68 @@ func main() {
 	// Exceeding capacity to trigger eviction
 	cache.Put("D", 4)
 	fmt.Println(cache.Get("B")) // Output: nil, false (Evicted)
+
+	// Shrinking evicts the least recently used entries right away
+	resizable := NewLRUCache(5)
+	for _, key := range []string{"k1", "k2", "k3", "k4", "k5"} {
+		resizable.Put(key, key)
+	}
+	resizable.Resize(2)
+	fmt.Println(resizable.Get("k3")) // Output: nil, false (Evicted)
+	fmt.Println(resizable.Get("k5")) // Output: k5, true
+
+	// LRU-2 keeps a key used twice even through a one-time scan
+	lruK := NewLRUCache(3)
+	lru2, err := NewLRUKEviction(2)
+	if err != nil {
+		panic(err)
+	}
+	lruK.SetEvictionStrategy(lru2)
+	lruK.Put("hot", 1)
+	lruK.Get("hot")
+	for _, key := range []string{"s1", "s2", "s3", "s4"} {
+		lruK.Put(key, 0)
+	}
+	fmt.Println(lruK.Get("hot")) // Output: 1, true
+
+	// FIFO evicts the first inserted key even though it was just read
+	fifo := NewLRUCache(2)
+	fifo.SetEvictionStrategy(&FIFOEviction{})
+	fifo.Put("first", 1)
+	fifo.Put("second", 2)
+	fifo.Get("first")
+	fifo.Put("third", 3)
+	fmt.Println(fifo.Get("first")) // Output: nil, false (Evicted)
+
+	// The pinned key outlives more recently used low priority keys
+	prioritized := NewLRUCache(2)
+	prioritized.SetEvictionStrategy(&PriorityEviction{})
+	prioritized.PutWithPriority("pinned", 1, 10)
+	prioritized.Put("low1", 2)
+	prioritized.Get("pinned")
+	prioritized.Get("low1")
+	prioritized.Put("low2", 3)
+	fmt.Println(prioritized.Get("pinned")) // Output: 1, true
+	fmt.Println(prioritized.Get("low1"))   // Output: nil, false (Evicted)
+
+	// Warming a size-3 cache with 5 entries keeps the last 3 keys
+	warm := NewLRUCache(3)
+	warm.Warm([]CacheItem{{"w1", 1}, {"w2", 2}, {"w3", 3}, {"w4", 4}, {"w5", 5}})
+	fmt.Println(warm.Get("w2")) // Output: nil, false (Skipped)
+	fmt.Println(warm.Get("w3")) // Output: 3, true
+
+	// Batch reads skip missing keys and refresh the hits, so b2 is evicted next
+	batch := NewLRUCache(3)
+	batch.PutMultiple(map[string]interface{}{"b1": 1, "b2": 2, "b3": 3})
+	fmt.Println(batch.GetMultiple([]string{"b1", "b3", "missing"})) // Output: map[b1:1 b3:3]
+	batch.Put("b4", 4)
+	fmt.Println(batch.Get("b2")) // Output: nil, false (Evicted)
+
+	// TypedCache returns ints directly, Put("x", "one") would not compile
+	counts := NewTypedCache[int](2)
+	counts.Put("x", 1)
+	counts.Put("y", 2)
+	if x, ok := counts.Get("x"); ok {
+		fmt.Println(x + 1) // Output: 2
+	}
 }
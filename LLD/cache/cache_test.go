@@ -0,0 +1,223 @@
+package main
+
+import "testing"
+
+func TestNewLRUKEvictionRejectsKBelowOne(t *testing.T) {
+	for _, k := range []int{0, -1} {
+		if _, err := NewLRUKEviction(k); err == nil {
+			t.Errorf("NewLRUKEviction(%d) succeeded, want an error", k)
+		}
+	}
+}
+
+func TestLRUKKeepsRepeatedKeyThroughScan(t *testing.T) {
+	lru2, err := NewLRUKEviction(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewLRUCache(3)
+	cache.SetEvictionStrategy(lru2)
+	cache.Put("hot", 1)
+	cache.Get("hot")
+	for _, key := range []string{"s1", "s2", "s3", "s4", "s5"} {
+		cache.Put(key, 0)
+	}
+
+	if value, ok := cache.Get("hot"); !ok || value != 1 {
+		t.Fatalf("Get(hot) = %v, %v, want 1, true", value, ok)
+	}
+	// the scan keys evict each other, the latest two stay
+	if _, ok := cache.Get("s3"); ok {
+		t.Fatal("s3 still cached, want it evicted")
+	}
+	for _, key := range []string{"s4", "s5"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Fatalf("%s missing, want it cached", key)
+		}
+	}
+}
+
+func TestLRUKWithK1ActsLikeLRU(t *testing.T) {
+	lru1, err := NewLRUKEviction(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewLRUCache(2)
+	cache.SetEvictionStrategy(lru1)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Get("a")
+	cache.Put("c", 3)
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("b still cached, want the least recently used key evicted")
+	}
+}
+
+func TestLRUKStructWithKBelowOneActsAsK1(t *testing.T) {
+	for _, k := range []int{0, -3} {
+		cache := NewLRUCache(2)
+		cache.SetEvictionStrategy(&LRUKEviction{K: k})
+		cache.Put("a", 1)
+		cache.Put("b", 2)
+		cache.Get("a")
+		cache.Put("c", 3)
+		if _, ok := cache.Get("b"); ok {
+			t.Fatalf("K=%d: b still cached, want the least recently used key evicted", k)
+		}
+	}
+}
+
+func TestWarmKeepsMostRecentEntries(t *testing.T) {
+	cache := NewLRUCache(3)
+	cache.Warm([]CacheItem{{"w5", 5}, {"w1", 1}, {"w4", 4}, {"w2", 2}, {"w3", 3}})
+
+	for _, key := range []string{"w5", "w1"} {
+		if _, ok := cache.Get(key); ok {
+			t.Errorf("%s cached, want it skipped", key)
+		}
+	}
+	for key, want := range map[string]int{"w4": 4, "w2": 2, "w3": 3} {
+		if value, ok := cache.Get(key); !ok || value != want {
+			t.Errorf("Get(%s) = %v, %v, want %d, true", key, value, ok, want)
+		}
+	}
+}
+
+func TestWarmOrderDecidesEviction(t *testing.T) {
+	cache := NewLRUCache(3)
+	// a repeated key counts once, its latest value wins
+	cache.Warm([]CacheItem{{"a", 1}, {"b", 2}, {"a", 3}, {"c", 4}})
+
+	cache.Put("d", 5) // evicts b, the least recent warmed entry
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("b still cached, want it evicted")
+	}
+	if value, ok := cache.Get("a"); !ok || value != 3 {
+		t.Fatalf("Get(a) = %v, %v, want 3, true", value, ok)
+	}
+}
+
+func TestTypedCacheGetsTypedValues(t *testing.T) {
+	cache := NewTypedCache[int](2)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+
+	var sum int
+	for _, key := range []string{"a", "b"} {
+		value, ok := cache.Get(key)
+		if !ok {
+			t.Fatalf("%s missing", key)
+		}
+		sum += value // an int, no assertion needed
+	}
+	if sum != 3 {
+		t.Fatalf("sum = %d, want 3", sum)
+	}
+
+	cache.Put("c", 3) // the underlying LRU evicts a
+	if value, ok := cache.Get("a"); ok || value != 0 {
+		t.Fatalf("Get(a) = %d, %v, want the zero value and false", value, ok)
+	}
+}
+
+func TestResizeEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUCache(5)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		cache.Put(key, key)
+	}
+	cache.Get("a") // a is now the most recently used
+
+	cache.Resize(2)
+	for _, key := range []string{"b", "c", "d"} {
+		if _, ok := cache.Get(key); ok {
+			t.Errorf("%s cached after shrinking, want it evicted", key)
+		}
+	}
+	for _, key := range []string{"a", "e"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s evicted, want it kept", key)
+		}
+	}
+
+	cache.Resize(4)
+	cache.Put("f", "f")
+	cache.Put("g", "g")
+	if len(cache.data) != 4 {
+		t.Fatalf("%d entries after growing to 4, want 4", len(cache.data))
+	}
+}
+
+func TestFIFOEvictsOldestInsertedEvenIfRead(t *testing.T) {
+	cache := NewLRUCache(3)
+	cache.SetEvictionStrategy(&FIFOEviction{})
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Put("c", 3)
+	cache.Get("a")
+	cache.Put("a", 10) // updating doesn't reinsert
+
+	cache.Put("d", 4)
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("a still cached, want the oldest inserted key evicted")
+	}
+	cache.Put("e", 5)
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("b still cached, want it evicted next")
+	}
+	for _, key := range []string{"c", "d", "e"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s missing", key)
+		}
+	}
+}
+
+func TestPriorityEvictsLowestFirst(t *testing.T) {
+	cache := NewLRUCache(3)
+	cache.SetEvictionStrategy(&PriorityEviction{})
+	cache.PutWithPriority("pinned", "config", 10)
+	cache.PutWithPriority("low1", 1, 1)
+	cache.PutWithPriority("low2", 2, 1)
+	cache.Get("low1")
+	cache.Get("low2")
+
+	// pinned is the least recently used, but the low priority keys go first,
+	// least recently used among them
+	cache.PutWithPriority("low3", 3, 1)
+	if _, ok := cache.Get("low1"); ok {
+		t.Fatal("low1 still cached, want it evicted")
+	}
+	cache.Put("plain", 4) // priority 0
+	if _, ok := cache.Get("low2"); ok {
+		t.Fatal("low2 still cached, want it evicted")
+	}
+	if value, ok := cache.Get("pinned"); !ok || value != "config" {
+		t.Fatalf("Get(pinned) = %v, %v, want config, true", value, ok)
+	}
+
+	cache.Put("pinned", "updated") // keeps priority 10
+	cache.Put("other", 5)
+	if _, ok := cache.Get("pinned"); !ok {
+		t.Fatal("pinned evicted after a Put without priority")
+	}
+}
+
+func TestGetMultipleUpdatesRecency(t *testing.T) {
+	cache := NewLRUCache(3)
+	cache.PutMultiple(map[string]interface{}{"a": 1, "b": 2, "c": 3})
+
+	got := cache.GetMultiple([]string{"a", "missing", "b"})
+	if len(got) != 2 || got["a"] != 1 || got["b"] != 2 {
+		t.Fatalf("GetMultiple = %v, want a and b only", got)
+	}
+
+	// c wasn't read, so it's the least recently used
+	cache.Put("d", 4)
+	if _, ok := cache.Get("c"); ok {
+		t.Fatal("c still cached, want it evicted")
+	}
+	for _, key := range []string{"a", "b", "d"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s missing", key)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -14,6 +17,11 @@ const (
 	Lt  Operator = "<"
 	Gte Operator = ">="
 	Lte Operator = "<="
+
+	// case-insensitive matching of string columns
+	Like       Operator = "LIKE" // substring
+	StartsWith Operator = "STARTS WITH"
+	EndsWith   Operator = "ENDS WITH"
 )
 
 type LogicalOperator string
@@ -28,6 +36,24 @@ type Query interface {
 	Evaluate(row map[string]interface{}) bool
 }
 
+// ErrTypeMismatch is returned by queries comparing values that can't be
+// compared, eg. an int column with a string
+var ErrTypeMismatch = errors.New("type mismatch")
+
+// checkedQuery is implemented by the queries that report type mismatches
+// instead of treating them as rows that don't match
+type checkedQuery interface {
+	EvaluateChecked(row map[string]interface{}) (bool, error)
+}
+
+// evaluate runs q on the row, with the mismatch checks when q supports them
+func evaluate(q Query, row map[string]interface{}) (bool, error) {
+	if cq, ok := q.(checkedQuery); ok {
+		return cq.EvaluateChecked(row)
+	}
+	return q.Evaluate(row), nil
+}
+
 // Leaf: Single Condition
 type Condition struct {
 	Column   string
@@ -43,6 +69,18 @@ func (c *Condition) Evaluate(row map[string]interface{}) bool {
 	return compare(val, c.Value, c.Operator)
 }
 
+func (c *Condition) EvaluateChecked(row map[string]interface{}) (bool, error) {
+	val, exists := row[c.Column]
+	if !exists {
+		return false, nil
+	}
+	ok, err := compareChecked(val, c.Value, c.Operator)
+	if err != nil {
+		return false, fmt.Errorf("%s %s %#v: %w", c.Column, c.Operator, c.Value, err)
+	}
+	return ok, nil
+}
+
 // Composite: Logical Combination of Queries
 type CompositeFilter struct {
 	LogicalOp LogicalOperator
@@ -70,6 +108,36 @@ func (cf *CompositeFilter) Evaluate(row map[string]interface{}) bool {
 	}
 }
 
+func (cf *CompositeFilter) EvaluateChecked(row map[string]interface{}) (bool, error) {
+	if cf.LogicalOp != And && cf.LogicalOp != Or {
+		return false, nil
+	}
+	for _, child := range cf.Children {
+		ok, err := evaluate(child, row)
+		if err != nil {
+			return false, err
+		}
+		if ok == (cf.LogicalOp == Or) {
+			return ok, nil
+		}
+	}
+	return cf.LogicalOp == And, nil
+}
+
+// Decorator: Negation of a Query
+type NotFilter struct {
+	Child Query
+}
+
+func (nf *NotFilter) Evaluate(row map[string]interface{}) bool {
+	return !nf.Child.Evaluate(row)
+}
+
+func (nf *NotFilter) EvaluateChecked(row map[string]interface{}) (bool, error) {
+	ok, err := evaluate(nf.Child, row)
+	return !ok, err
+}
+
 // Data types
 type ColumnDataType interface {
 	Validate(val interface{}) error
@@ -110,6 +178,7 @@ type SchemaMember struct {
 	Name     string
 	DataType ColumnDataType
 	Required bool
+	Unique   bool // no two rows may share a value, backed by an index
 }
 
 type Schema struct {
@@ -144,6 +213,7 @@ func (s *Schema) Validate(row map[string]interface{}) error {
 type Index struct {
 	ColumnName string
 	IndexMap   map[interface{}]map[int]struct{}
+	SortedKeys []interface{} // keys of IndexMap in ascending order for range scans
 }
 
 func NewIndex(column string) *Index {
@@ -156,6 +226,10 @@ func NewIndex(column string) *Index {
 func (idx *Index) Add(value interface{}, id int) {
 	if _, exists := idx.IndexMap[value]; !exists {
 		idx.IndexMap[value] = make(map[int]struct{})
+		pos := idx.keyPosition(value)
+		idx.SortedKeys = append(idx.SortedKeys, nil)
+		copy(idx.SortedKeys[pos+1:], idx.SortedKeys[pos:])
+		idx.SortedKeys[pos] = value
 	}
 	idx.IndexMap[value][id] = struct{}{}
 }
@@ -165,10 +239,130 @@ func (idx *Index) Remove(value interface{}, id int) {
 		delete(rows, id)
 		if len(rows) == 0 {
 			delete(idx.IndexMap, value)
+			pos := idx.keyPosition(value)
+			// less only orders ints and strings, other keys are looked up one by one
+			if pos >= len(idx.SortedKeys) || idx.SortedKeys[pos] != value {
+				pos = slices.Index(idx.SortedKeys, value)
+			}
+			if pos >= 0 {
+				idx.SortedKeys = append(idx.SortedKeys[:pos], idx.SortedKeys[pos+1:]...)
+			}
+		}
+	}
+}
+
+// keyPosition returns the position of the first sorted key not less than value
+func (idx *Index) keyPosition(value interface{}) int {
+	return sort.Search(len(idx.SortedKeys), func(i int) bool {
+		return !less(idx.SortedKeys[i], value)
+	})
+}
+
+// RangeLookup returns the sorted IDs of the rows whose value is within
+// [low, high]. A nil bound leaves that side open.
+func (idx *Index) RangeLookup(low, high interface{}) []int {
+	start := 0
+	if low != nil {
+		start = idx.keyPosition(low)
+	}
+	var ids []int
+	for _, key := range idx.SortedKeys[start:] {
+		if high != nil && less(high, key) {
+			break
 		}
+		for id := range idx.IndexMap[key] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// less orders index keys, ints before strings
+func less(a, b interface{}) bool {
+	switch x := a.(type) {
+	case int:
+		if y, ok := b.(int); ok {
+			return x < y
+		}
+		_, isString := b.(string)
+		return isString
+	case string:
+		if y, ok := b.(string); ok {
+			return x < y
+		}
+	}
+	return false
+}
+
+// CompositeIndex indexes rows by the values of several columns together,
+// rows missing any of the columns are left out
+type CompositeIndex struct {
+	Columns []string
+	index   *Index
+}
+
+func NewCompositeIndex(columns []string) *CompositeIndex {
+	return &CompositeIndex{
+		Columns: columns,
+		index:   NewIndex(compositeName(columns)),
 	}
 }
 
+// compositeName names the composite index over columns, eg. "name,city"
+func compositeName(columns []string) string {
+	return strings.Join(columns, ",")
+}
+
+// compositeKey joins the values into a single key, strings are quoted so
+// 1 and "1" don't collide
+func compositeKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%#v", v)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// key returns the composite key of row, false if a column is missing
+func (ci *CompositeIndex) key(row map[string]interface{}) (string, bool) {
+	values := make([]interface{}, len(ci.Columns))
+	for i, col := range ci.Columns {
+		val, ok := row[col]
+		if !ok {
+			return "", false
+		}
+		values[i] = val
+	}
+	return compositeKey(values), true
+}
+
+func (ci *CompositeIndex) Add(row map[string]interface{}, id int) {
+	if key, ok := ci.key(row); ok {
+		ci.index.Add(key, id)
+	}
+}
+
+func (ci *CompositeIndex) Remove(row map[string]interface{}, id int) {
+	if key, ok := ci.key(row); ok {
+		ci.index.Remove(key, id)
+	}
+}
+
+// Lookup returns the sorted IDs of the rows whose columns equal values
+func (ci *CompositeIndex) Lookup(values []interface{}) []int {
+	key := compositeKey(values)
+	return ci.index.RangeLookup(key, key)
+}
+
+// versionColumn is the hidden per-row version bumped on every update
+const versionColumn = "_version"
+
+var (
+	ErrVersionConflict = errors.New("version conflict")
+	ErrDuplicateValue  = errors.New("duplicate value")
+)
+
 // Table
 type Table struct {
 	Name      string
@@ -178,27 +372,62 @@ type Table struct {
 	Indexes   map[string]*Index
 	DataLock  sync.RWMutex
 	IndexLock sync.RWMutex
+	// keyed by the comma separated columns, eg. "name,city"
+	CompositeIndexes map[string]*CompositeIndex
 }
 
 func NewTable(name string, schema *Schema) *Table {
-	return &Table{
-		Name:    name,
-		Schema:  schema,
-		Data:    make(map[int]map[string]interface{}),
-		Indexes: make(map[string]*Index),
+	t := &Table{
+		Name:             name,
+		Schema:           schema,
+		Data:             make(map[int]map[string]interface{}),
+		Indexes:          make(map[string]*Index),
+		CompositeIndexes: make(map[string]*CompositeIndex),
+	}
+	// unique columns are always indexed so duplicates are found in O(1)
+	for col, member := range schema.Columns {
+		if member.Unique {
+			t.Indexes[col] = NewIndex(col)
+		}
+	}
+	return t
+}
+
+// checkUnique returns an error if a unique column value in row is already
+// used by a row other than id, callers must hold the data lock
+func (t *Table) checkUnique(id int, row map[string]interface{}) error {
+	for col, member := range t.Schema.Columns {
+		if !member.Unique {
+			continue
+		}
+		val, ok := row[col]
+		if !ok {
+			continue
+		}
+		for other := range t.Indexes[col].IndexMap[val] {
+			if other != id {
+				return fmt.Errorf("%w: %s=%v already exists in row %d", ErrDuplicateValue, col, val, other)
+			}
+		}
 	}
+	return nil
 }
 
 func (t *Table) Insert(row map[string]interface{}) (int, error) {
 	t.DataLock.Lock()
 	defer t.DataLock.Unlock()
 
+	row = copyRow(row) // the caller keeps its map, later edits to it can't bypass the schema
 	t.AutoID++
 	row["id"] = t.AutoID
+	row[versionColumn] = 1
 
 	if err := t.Schema.Validate(row); err != nil {
 		return 0, err
 	}
+	if err := t.checkUnique(t.AutoID, row); err != nil {
+		return 0, err
+	}
 	t.Data[t.AutoID] = row
 
 	for col, idx := range t.Indexes {
@@ -206,33 +435,139 @@ func (t *Table) Insert(row map[string]interface{}) (int, error) {
 			idx.Add(val, t.AutoID)
 		}
 	}
+	for _, ci := range t.CompositeIndexes {
+		ci.Add(row, t.AutoID)
+	}
 
 	return t.AutoID, nil
 }
 
+// InsertBatch inserts the rows under a single lock, indexing them once all
+// have been checked. The first invalid row rejects the whole batch.
+func (t *Table) InsertBatch(rows []map[string]interface{}) ([]int, error) {
+	t.DataLock.Lock()
+	defer t.DataLock.Unlock()
+
+	rows = copyRows(rows)
+	ids := make([]int, len(rows))
+	batchValues := make(map[string]map[interface{}]int) // unique column values seen earlier in the batch
+	for i, row := range rows {
+		id := t.AutoID + i + 1
+		row["id"] = id
+		row[versionColumn] = 1
+
+		if err := t.Schema.Validate(row); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		if err := t.checkUnique(id, row); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		for col, member := range t.Schema.Columns {
+			val, ok := row[col]
+			if !member.Unique || !ok {
+				continue
+			}
+			if batchValues[col] == nil {
+				batchValues[col] = make(map[interface{}]int)
+			}
+			if other, dup := batchValues[col][val]; dup {
+				return nil, fmt.Errorf("row %d: %w: %s=%v already in batch row %d", i, ErrDuplicateValue, col, val, other)
+			}
+			batchValues[col][val] = i
+		}
+		ids[i] = id
+	}
+
+	for i, row := range rows {
+		t.Data[ids[i]] = row
+	}
+	t.AutoID += len(rows)
+	for col, idx := range t.Indexes {
+		for i, row := range rows {
+			if val, ok := row[col]; ok {
+				idx.Add(val, ids[i])
+			}
+		}
+	}
+	for _, ci := range t.CompositeIndexes {
+		for i, row := range rows {
+			ci.Add(row, ids[i])
+		}
+	}
+
+	return ids, nil
+}
+
 func (t *Table) Update(id int, updated map[string]interface{}) error {
 	t.DataLock.Lock()
 	defer t.DataLock.Unlock()
 
+	return t.update(id, updated)
+}
+
+// UpdateIfVersion updates the row only if it's still at the expected version,
+// so a read-modify-write can't overwrite a concurrent update.
+func (t *Table) UpdateIfVersion(id, expectedVersion int, changes map[string]interface{}) error {
+	t.DataLock.Lock()
+	defer t.DataLock.Unlock()
+
 	row, exists := t.Data[id]
 	if !exists {
 		return errors.New("row not found")
 	}
+	if current := row[versionColumn].(int); current != expectedVersion {
+		return fmt.Errorf("%w: row %d is at version %d, expected %d", ErrVersionConflict, id, current, expectedVersion)
+	}
+	return t.update(id, changes)
+}
 
-	for k, v := range updated {
-		row[k] = v
+// RowVersion returns the row's version, it starts at 1 and is bumped on every update
+func (t *Table) RowVersion(id int) (int, error) {
+	t.DataLock.RLock()
+	defer t.DataLock.RUnlock()
+
+	row, exists := t.Data[id]
+	if !exists {
+		return 0, errors.New("row not found")
 	}
+	return row[versionColumn].(int), nil
+}
 
-	if err := t.Schema.Validate(row); err != nil {
+// update applies the changes, callers must hold the data lock
+func (t *Table) update(id int, updated map[string]interface{}) error {
+	row, exists := t.Data[id]
+	if !exists {
+		return errors.New("row not found")
+	}
+	if err := t.checkUnique(id, updated); err != nil {
 		return err
 	}
 
-	for col, idx := range t.Indexes {
-		if val, ok := updated[col]; ok {
-			idx.Remove(row[col], id)
-			idx.Add(val, id)
+	// validate a copy so a rejected update leaves the stored row and indexes untouched
+	candidate := copyRow(row)
+	for k, v := range updated {
+		if k != versionColumn {
+			candidate[k] = v
 		}
 	}
+	candidate[versionColumn] = row[versionColumn].(int) + 1
+	if err := t.Schema.Validate(candidate); err != nil {
+		return err
+	}
+
+	for _, ci := range t.CompositeIndexes {
+		ci.Remove(row, id)
+	}
+	for k, v := range updated {
+		if idx, ok := t.Indexes[k]; ok && k != versionColumn {
+			idx.Remove(row[k], id)
+			idx.Add(v, id)
+		}
+	}
+	for _, ci := range t.CompositeIndexes {
+		ci.Add(candidate, id)
+	}
+	t.Data[id] = candidate
 
 	return nil
 }
@@ -251,11 +586,22 @@ func (t *Table) Delete(id int) error {
 			idx.Remove(val, id)
 		}
 	}
+	for _, ci := range t.CompositeIndexes {
+		ci.Remove(row, id)
+	}
 
 	delete(t.Data, id)
 	return nil
 }
 
+// Count returns the number of rows currently in the table
+func (t *Table) Count() int {
+	t.DataLock.RLock()
+	defer t.DataLock.RUnlock()
+
+	return len(t.Data)
+}
+
 func (t *Table) CreateIndex(column string) {
 	t.IndexLock.Lock()
 	defer t.IndexLock.Unlock()
@@ -269,49 +615,333 @@ func (t *Table) CreateIndex(column string) {
 	t.Indexes[column] = idx
 }
 
+// CreateCompositeIndex indexes the rows by the columns together. Writers
+// update composite indexes under the data lock only, so it's held for both
+// the scan and publishing the index; no row can slip in between.
+func (t *Table) CreateCompositeIndex(columns []string) {
+	t.DataLock.Lock()
+	defer t.DataLock.Unlock()
+	t.IndexLock.Lock()
+	defer t.IndexLock.Unlock()
+
+	ci := NewCompositeIndex(columns)
+	for id, row := range t.Data {
+		ci.Add(row, id)
+	}
+	t.CompositeIndexes[compositeName(columns)] = ci
+}
+
+// LookupComposite returns the sorted IDs of the rows whose columns equal
+// values, using the composite index over exactly those columns
+func (t *Table) LookupComposite(columns []string, values []interface{}) ([]int, error) {
+	if len(columns) != len(values) {
+		return nil, errors.New("columns and values differ in length")
+	}
+	t.IndexLock.RLock()
+	ci, ok := t.CompositeIndexes[compositeName(columns)]
+	t.IndexLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no composite index on %s", compositeName(columns))
+	}
+
+	t.DataLock.RLock()
+	defer t.DataLock.RUnlock()
+	return ci.Lookup(values), nil
+}
+
+// compare is compareChecked with mismatched types never matching
 func compare(v1 interface{}, v2 interface{}, op Operator) bool {
-	switch a := v1.(type) {
-	case int:
-		b, _ := v2.(int)
-		switch op {
-		case Eq:
-			return a == b
-		case Gt:
-			return a > b
-		case Lt:
-			return a < b
-		case Gte:
-			return a >= b
-		case Lte:
-			return a <= b
+	ok, _ := compareChecked(v1, v2, op)
+	return ok
+}
+
+// compareChecked compares a column value with a query value. Numbers are
+// compared as float64 when one of them isn't an int, values of other
+// different types return ErrTypeMismatch.
+func compareChecked(v1 interface{}, v2 interface{}, op Operator) (bool, error) {
+	if a, ok := v1.(int); ok {
+		if b, ok := v2.(int); ok {
+			return compareOrdered(a, b, op), nil
 		}
-	case string:
-		b, _ := v2.(string)
-		switch op {
-		case Eq:
-			return a == b
+	}
+	if a, ok := toFloat(v1); ok {
+		if b, ok := toFloat(v2); ok {
+			return compareOrdered(a, b, op), nil
 		}
+		return false, fmt.Errorf("%w: cannot compare %T with %T", ErrTypeMismatch, v1, v2)
+	}
+
+	a, ok := v1.(string)
+	if !ok {
+		return false, fmt.Errorf("%w: unsupported column type %T", ErrTypeMismatch, v1)
+	}
+	b, ok := v2.(string)
+	if !ok {
+		return false, fmt.Errorf("%w: cannot compare %T with %T", ErrTypeMismatch, v1, v2)
+	}
+	switch op {
+	case Eq:
+		return a == b, nil
+	case Like:
+		return strings.Contains(strings.ToLower(a), strings.ToLower(b)), nil
+	case StartsWith:
+		return strings.HasPrefix(strings.ToLower(a), strings.ToLower(b)), nil
+	case EndsWith:
+		return strings.HasSuffix(strings.ToLower(a), strings.ToLower(b)), nil
+	}
+	return false, nil
+}
+
+// compareOrdered applies the comparison operators, the string matching ones never match
+func compareOrdered[T int | float64](a, b T, op Operator) bool {
+	switch op {
+	case Eq:
+		return a == b
+	case Gt:
+		return a > b
+	case Lt:
+		return a < b
+	case Gte:
+		return a >= b
+	case Lte:
+		return a <= b
 	}
 	return false
 }
 
-// New Query method using Composite
+// toFloat converts the numeric types a query can hold
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// indexCandidates narrows a single condition on an indexed column down to
+// the rows in the matching index range, the condition is still evaluated on them.
+// Values of another type are left to the row scan, which coerces or rejects them.
+func (t *Table) indexCandidates(q Query) ([]int, bool) {
+	cond, ok := q.(*Condition)
+	if !ok || !t.sameType(cond.Column, cond.Value) {
+		return nil, false
+	}
+	t.IndexLock.RLock()
+	idx, ok := t.Indexes[cond.Column]
+	t.IndexLock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	switch cond.Operator {
+	case Eq:
+		return idx.RangeLookup(cond.Value, cond.Value), true
+	case Gt, Gte:
+		return idx.RangeLookup(cond.Value, nil), true
+	case Lt, Lte:
+		return idx.RangeLookup(nil, cond.Value), true
+	}
+	return nil, false
+}
+
+// sameType reports whether v has the type of the column values, only then
+// can the column index answer a condition on it
+func (t *Table) sameType(column string, v interface{}) bool {
+	member, ok := t.Schema.Columns[column]
+	if !ok {
+		return false
+	}
+	switch member.DataType.(type) {
+	case *IntDataType:
+		_, ok = v.(int)
+	case *StringDataType:
+		_, ok = v.(string)
+	default:
+		ok = false
+	}
+	return ok
+}
+
+// copyRows copies each row with copyRow
+func copyRows(rows []map[string]interface{}) []map[string]interface{} {
+	copied := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		copied[i] = copyRow(row)
+	}
+	return copied
+}
+
+// copyRow returns a copy of the row so callers can't change the table data,
+// values are ints and strings so copying the map is enough. The hidden
+// version is left out, see RowVersion.
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		if k != versionColumn {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// New Query method using Composite, the rows returned are copies
 func (t *Table) Query(q Query) ([]map[string]interface{}, error) {
 	t.DataLock.RLock()
 	defer t.DataLock.RUnlock()
 
 	var result []map[string]interface{}
+	if ids, ok := t.indexCandidates(q); ok {
+		for _, id := range ids {
+			row := t.Data[id]
+			matched, err := evaluate(q, row)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				result = append(result, copyRow(row))
+			}
+		}
+		return result, nil
+	}
 	for _, row := range t.Data {
 		if row == nil {
 			continue
 		}
-		if q.Evaluate(row) {
-			result = append(result, row)
+		matched, err := evaluate(q, row)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			result = append(result, copyRow(row))
 		}
 	}
 	return result, nil
 }
 
+// CountWhere returns the number of rows matching q without copying them.
+// Equality on an indexed column is answered from the index alone. Like
+// Query it fails with ErrTypeMismatch rather than counting nothing.
+func (t *Table) CountWhere(q Query) (int, error) {
+	t.DataLock.RLock()
+	defer t.DataLock.RUnlock()
+
+	if cond, ok := q.(*Condition); ok && cond.Operator == Eq && t.sameType(cond.Column, cond.Value) {
+		t.IndexLock.RLock()
+		idx, indexed := t.Indexes[cond.Column]
+		count := 0
+		if indexed {
+			count = len(idx.IndexMap[cond.Value])
+		}
+		t.IndexLock.RUnlock()
+		if indexed {
+			return count, nil
+		}
+	}
+
+	count := 0
+	if ids, ok := t.indexCandidates(q); ok {
+		for _, id := range ids {
+			matched, err := evaluate(q, t.Data[id])
+			if err != nil {
+				return 0, err
+			}
+			if matched {
+				count++
+			}
+		}
+		return count, nil
+	}
+	for _, row := range t.Data {
+		if row == nil {
+			continue
+		}
+		matched, err := evaluate(q, row)
+		if err != nil {
+			return 0, err
+		}
+		if matched {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// lockOrder returns the two tables in the order their locks are taken, by
+// name and then address, so two joins over the same tables can't deadlock
+func lockOrder(a, b *Table) (*Table, *Table) {
+	if a.Name > b.Name || (a.Name == b.Name && fmt.Sprintf("%p", a) > fmt.Sprintf("%p", b)) {
+		return b, a
+	}
+	return a, b
+}
+
+// InnerJoin returns the combined rows where left[leftCol] == right[rightCol].
+// Columns are prefixed with their table name, eg. "users.id", to avoid collisions.
+// The right table's index on rightCol is used when there is one.
+func InnerJoin(left, right *Table, leftCol, rightCol string) []map[string]interface{} {
+	first, second := lockOrder(left, right)
+	first.DataLock.RLock()
+	defer first.DataLock.RUnlock()
+	if second != first {
+		second.DataLock.RLock()
+		defer second.DataLock.RUnlock()
+	}
+	right.IndexLock.RLock()
+	idx := right.Indexes[rightCol]
+	right.IndexLock.RUnlock()
+
+	// keep the output in left row order
+	leftIDs := make([]int, 0, len(left.Data))
+	for id := range left.Data {
+		leftIDs = append(leftIDs, id)
+	}
+	sort.Ints(leftIDs)
+
+	var result []map[string]interface{}
+	for _, leftID := range leftIDs {
+		leftRow := left.Data[leftID]
+		val, ok := leftRow[leftCol]
+		if !ok {
+			continue
+		}
+
+		var matches []int
+		if idx != nil {
+			for id := range idx.IndexMap[val] {
+				matches = append(matches, id)
+			}
+		} else {
+			for id, row := range right.Data {
+				if rv, ok := row[rightCol]; ok && compare(rv, val, Eq) {
+					matches = append(matches, id)
+				}
+			}
+		}
+		sort.Ints(matches)
+
+		for _, rightID := range matches {
+			joined := make(map[string]interface{})
+			for col, v := range leftRow {
+				if col != versionColumn {
+					joined[left.Name+"."+col] = v
+				}
+			}
+			for col, v := range right.Data[rightID] {
+				if col != versionColumn {
+					joined[right.Name+"."+col] = v
+				}
+			}
+			result = append(result, joined)
+		}
+	}
+	return result
+}
+
 // Database
 type Database struct {
 	Name   string
@@ -329,6 +959,24 @@ func (db *Database) CreateTable(name string, schema *Schema) {
 	db.Tables[name] = NewTable(name, schema)
 }
 
+// ListTables returns the table names in alphabetical order
+func (db *Database) ListTables() []string {
+	names := make([]string, 0, len(db.Tables))
+	for name := range db.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (db *Database) DropTable(name string) error {
+	if _, exists := db.Tables[name]; !exists {
+		return fmt.Errorf("table %q not found", name)
+	}
+	delete(db.Tables, name)
+	return nil
+}
+
 // Server
 type Server struct {
 	Databases map[string]*Database
@@ -388,4 +1036,126 @@ func main() {
 	for _, r := range results {
 		fmt.Println(r)
 	}
+
+	// name == "Alice" AND NOT (city == "Paris")
+	notParis, _ := users.Query(&CompositeFilter{
+		LogicalOp: And,
+		Children: []Query{
+			&Condition{Column: "name", Operator: Eq, Value: "Alice"},
+			&NotFilter{Child: &Condition{Column: "city", Operator: Eq, Value: "Paris"}},
+		},
+	})
+	for _, r := range notParis {
+		fmt.Println("Alice outside Paris:", r["city"])
+	}
+
+	// Optimistic update: the second writer still holds version 1
+	if err := users.UpdateIfVersion(2, 1, map[string]interface{}{"age": 26}); err != nil {
+		fmt.Println(err)
+	}
+	if err := users.UpdateIfVersion(2, 1, map[string]interface{}{"age": 27}); errors.Is(err, ErrVersionConflict) {
+		fmt.Println(err)
+	}
+	fmt.Println(users.Data[2])
+
+	// name LIKE "ali" matches Alice whatever the case
+	for _, cond := range []*Condition{
+		{Column: "name", Operator: Like, Value: "ali"},
+		{Column: "name", Operator: StartsWith, Value: "CH"},
+		{Column: "city", Operator: EndsWith, Value: "don"},
+		{Column: "age", Operator: Like, Value: "3"},
+	} {
+		rows, err := users.Query(cond)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%s %s %q: %d rows\n", cond.Column, cond.Operator, cond.Value, len(rows))
+	}
+
+	// Query results are copies, changing them leaves the table alone
+	bob, _ := users.Query(&Condition{Column: "name", Operator: Eq, Value: "Bob"})
+	bob[0]["age"] = 99
+	bob, _ = users.Query(&Condition{Column: "name", Operator: Eq, Value: "Bob"})
+	fmt.Println("Bob's age after editing a result:", bob[0]["age"])
+
+	// A batch with an invalid row inserts nothing
+	_, err := users.InsertBatch([]map[string]interface{}{
+		{"name": "Dave", "age": 40, "city": "Oslo"},
+		{"name": "Erin", "age": 22, "city": "Lima"},
+		{"name": "Frank", "age": -1, "city": "Rome"},
+	})
+	fmt.Println("batch rejected:", err, "rows:", users.Count())
+	batchIDs, _ := users.InsertBatch([]map[string]interface{}{
+		{"name": "Dave", "age": 40, "city": "Oslo"},
+		{"name": "Erin", "age": 22, "city": "Lima"},
+	})
+	fmt.Println("batch inserted:", batchIDs, "rows:", users.Count())
+
+	// A rejected update leaves the row as it was
+	if err := users.Update(2, map[string]interface{}{"city": "Madrid", "age": 200}); err != nil {
+		fmt.Println("update rejected:", err)
+	}
+	bob, _ = users.Query(&Condition{Column: "name", Operator: Eq, Value: "Bob"})
+	fmt.Println("Bob after the rejected update:", bob[0]["age"], bob[0]["city"])
+
+	// Composite index lookups follow updates
+	users.CreateCompositeIndex([]string{"name", "city"})
+	ids, _ := users.LookupComposite([]string{"name", "city"}, []interface{}{"Alice", "Paris"})
+	fmt.Println("Alice in Paris:", ids)
+	users.Update(1, map[string]interface{}{"city": "Rome"})
+	ids, _ = users.LookupComposite([]string{"name", "city"}, []interface{}{"Alice", "Paris"})
+	fmt.Println("Alice in Paris after moving:", ids)
+	ids, _ = users.LookupComposite([]string{"name", "city"}, []interface{}{"Alice", "Rome"})
+	fmt.Println("Alice in Rome:", ids)
+
+	// Range scans only touch the index entries in range
+	users.CreateIndex("age")
+	fmt.Println("age in [28, 30]:", users.Indexes["age"].RangeLookup(28, 30))
+	adults, _ := users.Query(&Condition{Column: "age", Operator: Gte, Value: 30})
+	counted, _ := users.CountWhere(&Condition{Column: "age", Operator: Gte, Value: 30})
+	fmt.Println("age >= 30:", len(adults), "counted:", counted)
+	if rows, err := users.Query(&Condition{Column: "age", Operator: Gt, Value: 29.5}); err == nil {
+		fmt.Println("age > 29.5:", len(rows))
+	}
+	if _, err := users.Query(&Condition{Column: "age", Operator: Eq, Value: "thirty"}); errors.Is(err, ErrTypeMismatch) {
+		fmt.Println(err)
+	}
+	alices, _ := users.CountWhere(&Condition{Column: "name", Operator: Eq, Value: "Alice"})
+	fmt.Println("rows named Alice:", alices)
+
+	db.CreateTable("accounts", NewSchema([]SchemaMember{
+		{Name: "email", DataType: &StringDataType{AllowNull: false}, Required: true, Unique: true},
+	}))
+	accounts := db.Tables["accounts"]
+	accounts.Insert(map[string]interface{}{"email": "alice@example.com"})
+	if _, err := accounts.Insert(map[string]interface{}{"email": "alice@example.com"}); errors.Is(err, ErrDuplicateValue) {
+		fmt.Println(err)
+	}
+
+	db.CreateTable("orders", NewSchema([]SchemaMember{
+		{Name: "user_id", DataType: &IntDataType{MinValue: 0, MaxValue: 10000}, Required: true},
+		{Name: "item", DataType: &StringDataType{AllowNull: false}, Required: true},
+	}))
+	orders := db.Tables["orders"]
+	orders.CreateIndex("user_id")
+	orders.Insert(map[string]interface{}{"user_id": 1, "item": "Book"})
+	orders.Insert(map[string]interface{}{"user_id": 1, "item": "Pen"})
+	orders.Insert(map[string]interface{}{"user_id": 4, "item": "Lamp"})
+
+	fmt.Println("users JOIN orders ON users.id = orders.user_id:")
+	for _, r := range InnerJoin(users, orders, "id", "user_id") {
+		fmt.Println(r["users.name"], r["orders.item"])
+	}
+
+	fmt.Println("Tables:", db.ListTables())
+	orders.Delete(3)
+	fmt.Println("Orders left:", orders.Count())
+	if err := db.DropTable("accounts"); err != nil {
+		fmt.Println(err)
+	}
+	if err := db.DropTable("accounts"); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("Tables after drop:", db.ListTables())
 }
@@ -108,6 +108,17 @@ func (t *Table) Insert(row map[string]interface{}) (int, error) {
 	return t.AutoID, nil
 }
 
+// copyRow returns a copy of the row so callers can't change the table data,
+// values are ints and strings so copying the map is enough
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		copied[k] = v
+	}
+	return copied
+}
+
+// QuerySimple returns copies of the rows matching every filter
 func (t *Table) QuerySimple(filters map[string]interface{}) ([]map[string]interface{}, error) {
 	t.DataLock.RLock()
 	defer t.DataLock.RUnlock()
@@ -126,7 +137,7 @@ func (t *Table) QuerySimple(filters map[string]interface{}) ([]map[string]interf
 			}
 		}
 		if match {
-			results = append(results, row)
+			results = append(results, copyRow(row))
 		}
 	}
 
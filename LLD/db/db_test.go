@@ -0,0 +1,525 @@
+package main
+
+// db.go and db_demo.go are separate programs, run with: go test db.go db_test.go
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newUsers returns a users table holding the given names, ids from 1
+func newUsers(names ...string) *Table {
+	users := NewTable("users", NewSchema([]SchemaMember{
+		{Name: "id", DataType: &IntDataType{MinValue: 0, MaxValue: 10000}, Required: true},
+		{Name: "name", DataType: &StringDataType{}, Required: true},
+		{Name: "age", DataType: &IntDataType{MinValue: 0, MaxValue: 150}},
+		{Name: "city", DataType: &StringDataType{AllowNull: true}},
+	}))
+	for _, name := range names {
+		if _, err := users.Insert(map[string]interface{}{"name": name, "age": 30}); err != nil {
+			panic(err)
+		}
+	}
+	return users
+}
+
+// newOrders returns an orders table with one order per user ID given
+func newOrders(userIDs ...int) *Table {
+	orders := NewTable("orders", NewSchema([]SchemaMember{
+		{Name: "user_id", DataType: &IntDataType{MinValue: 0, MaxValue: 10000}, Required: true},
+		{Name: "item", DataType: &StringDataType{}},
+	}))
+	for _, id := range userIDs {
+		if _, err := orders.Insert(map[string]interface{}{"user_id": id, "item": "item"}); err != nil {
+			panic(err)
+		}
+	}
+	return orders
+}
+
+func TestInnerJoin(t *testing.T) {
+	users := newUsers("Alice", "Bob", "Carol")
+	orders := newOrders(1, 1, 3, 9)
+
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			orders.CreateIndex("user_id")
+		}
+		rows := InnerJoin(users, orders, "id", "user_id")
+		var got []string
+		for _, r := range rows {
+			got = append(got, r["users.name"].(string))
+		}
+		if len(got) != 3 || got[0] != "Alice" || got[1] != "Alice" || got[2] != "Carol" {
+			t.Fatalf("indexed %t: joined %v, want [Alice Alice Carol]", indexed, got)
+		}
+	}
+}
+
+func TestInnerJoinBothWaysWithWriters(t *testing.T) {
+	users := newUsers("Alice", "Bob")
+	orders := newOrders(1, 2)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(4)
+			go func() { defer wg.Done(); InnerJoin(users, orders, "id", "user_id") }()
+			go func() { defer wg.Done(); InnerJoin(orders, users, "user_id", "id") }()
+			go func() { defer wg.Done(); users.Update(1, map[string]interface{}{"age": 31}) }()
+			go func() { defer wg.Done(); orders.Update(1, map[string]interface{}{"item": "pen"}) }()
+		}
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("joins in opposite directions deadlocked")
+	}
+}
+
+func TestInsertCopiesCallerRows(t *testing.T) {
+	users := newUsers()
+	row := map[string]interface{}{"name": "Alice", "age": 30}
+	id, err := users.Insert(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := []map[string]interface{}{{"name": "Bob", "age": 25}}
+	if _, err := users.InsertBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := row["id"]; ok {
+		t.Fatalf("Insert wrote into the caller's row: %v", row)
+	}
+
+	// bypassing the schema through the caller's maps must not reach the table
+	row["age"] = "old"
+	batch[0]["name"] = "Mallory"
+	if users.Data[id]["age"] != 30 {
+		t.Fatalf("stored age = %v, want 30", users.Data[id]["age"])
+	}
+	if rows, err := users.Query(&Condition{Column: "name", Operator: Eq, Value: "Bob"}); err != nil || len(rows) != 1 {
+		t.Fatalf("query Bob = %v, %v, want the batch row unchanged", rows, err)
+	}
+}
+
+func TestCountWhereMatchesQuery(t *testing.T) {
+	users := newUsers("Alice", "Bob", "Alice")
+	users.Insert(map[string]interface{}{"name": "Carol", "age": 45})
+
+	queries := []Query{
+		&Condition{Column: "name", Operator: Eq, Value: "Alice"},
+		&Condition{Column: "age", Operator: Gte, Value: 40},
+		&NotFilter{Child: &Condition{Column: "name", Operator: Eq, Value: "Bob"}},
+	}
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			users.CreateIndex("name")
+			users.CreateIndex("age")
+		}
+		for _, q := range queries {
+			rows, err := users.Query(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := users.CountWhere(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != len(rows) {
+				t.Errorf("indexed %t: CountWhere(%v) = %d, Query found %d", indexed, q, count, len(rows))
+			}
+		}
+
+		_, queryErr := users.Query(&Condition{Column: "age", Operator: Eq, Value: "thirty"})
+		count, err := users.CountWhere(&Condition{Column: "age", Operator: Eq, Value: "thirty"})
+		if !errors.Is(err, ErrTypeMismatch) || !errors.Is(queryErr, ErrTypeMismatch) {
+			t.Errorf("indexed %t: mismatched type counted %d with %v, Query gave %v, want ErrTypeMismatch", indexed, count, err, queryErr)
+		}
+	}
+}
+
+func TestUpdateIfVersionRejectsStaleWrite(t *testing.T) {
+	users := newUsers("Alice")
+	if v, err := users.RowVersion(1); err != nil || v != 1 {
+		t.Fatalf("version after insert = %v, %v, want 1", v, err)
+	}
+
+	// both writers read version 1, only the first may write
+	if err := users.UpdateIfVersion(1, 1, map[string]interface{}{"age": 31}); err != nil {
+		t.Fatal(err)
+	}
+	err := users.UpdateIfVersion(1, 1, map[string]interface{}{"age": 40})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("stale update: got %v, want ErrVersionConflict", err)
+	}
+	if v, _ := users.RowVersion(1); users.Data[1]["age"] != 31 || v != 2 {
+		t.Fatalf("row = %v at version %d, want age 31 at version 2", users.Data[1], v)
+	}
+
+	if err := users.Update(1, map[string]interface{}{"city": "Paris"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.UpdateIfVersion(1, 3, map[string]interface{}{"age": 32}); err != nil {
+		t.Fatalf("update at the current version: %v", err)
+	}
+}
+
+func TestUniqueColumnRejectsDuplicates(t *testing.T) {
+	accounts := NewTable("accounts", NewSchema([]SchemaMember{
+		{Name: "id", DataType: &IntDataType{MinValue: 0, MaxValue: 10000}, Required: true},
+		{Name: "email", DataType: &StringDataType{}, Required: true, Unique: true},
+	}))
+	if _, err := accounts.Insert(map[string]interface{}{"email": "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	bob, err := accounts.Insert(map[string]interface{}{"email": "bob@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := accounts.Insert(map[string]interface{}{"email": "alice@example.com"}); !errors.Is(err, ErrDuplicateValue) {
+		t.Fatalf("duplicate insert: got %v, want ErrDuplicateValue", err)
+	}
+	if err := accounts.Update(bob, map[string]interface{}{"email": "alice@example.com"}); !errors.Is(err, ErrDuplicateValue) {
+		t.Fatalf("duplicate update: got %v, want ErrDuplicateValue", err)
+	}
+	if err := accounts.Update(bob, map[string]interface{}{"email": "bob@example.com"}); err != nil {
+		t.Fatalf("keeping its own value: %v", err)
+	}
+	_, err = accounts.InsertBatch([]map[string]interface{}{{"email": "carol@example.com"}, {"email": "carol@example.com"}})
+	if !errors.Is(err, ErrDuplicateValue) {
+		t.Fatalf("duplicate within a batch: got %v, want ErrDuplicateValue", err)
+	}
+	if n := accounts.Count(); n != 2 {
+		t.Fatalf("%d rows, want 2", n)
+	}
+
+	// a deleted value is free again
+	if err := accounts.Delete(bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := accounts.Insert(map[string]interface{}{"email": "bob@example.com"}); err != nil {
+		t.Fatalf("reusing a deleted value: %v", err)
+	}
+}
+
+func TestIndexRangeLookup(t *testing.T) {
+	users := newUsers()
+	for i, age := range []int{25, 40, 30, 35, 30, 60} {
+		users.Insert(map[string]interface{}{"name": fmt.Sprint("user", i), "age": age})
+	}
+	users.CreateIndex("age")
+	idx := users.Indexes["age"]
+
+	for _, tc := range []struct {
+		low, high interface{}
+		want      []int
+	}{
+		{30, nil, []int{2, 3, 4, 5, 6}},
+		{30, 35, []int{3, 4, 5}},
+		{nil, 29, []int{1}},
+		{41, 59, nil},
+	} {
+		if got := idx.RangeLookup(tc.low, tc.high); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("RangeLookup(%v, %v) = %v, want %v", tc.low, tc.high, got, tc.want)
+		}
+	}
+
+	// the sorted keys follow updates and deletes
+	users.Update(6, map[string]interface{}{"age": 33})
+	users.Delete(2)
+	if got := idx.RangeLookup(30, 35); !reflect.DeepEqual(got, []int{3, 4, 5, 6}) {
+		t.Fatalf("after update and delete got %v, want [3 4 5 6]", got)
+	}
+	rows, err := users.Query(&Condition{Column: "age", Operator: Gte, Value: 35})
+	if err != nil || len(rows) != 1 || rows[0]["id"] != 4 {
+		t.Fatalf("age >= 35 = %v, %v, want row 4", rows, err)
+	}
+}
+
+// names lists the name column of rows sorted by id
+func names(rows []map[string]interface{}) []string {
+	sort.Slice(rows, func(i, j int) bool { return rows[i]["id"].(int) < rows[j]["id"].(int) })
+	var got []string
+	for _, row := range rows {
+		got = append(got, row["name"].(string))
+	}
+	return got
+}
+
+func TestNotFilterInsideAnd(t *testing.T) {
+	users := newUsers("Alice", "Bob", "Carol", "Dave")
+	for id, city := range map[int]string{1: "Paris", 2: "London", 3: "Paris", 4: "Rome"} {
+		users.Update(id, map[string]interface{}{"city": city})
+	}
+	users.Update(4, map[string]interface{}{"age": 20})
+
+	// age >= 30 AND NOT (city == "Paris")
+	q := &CompositeFilter{LogicalOp: And, Children: []Query{
+		&Condition{Column: "age", Operator: Gte, Value: 30},
+		&NotFilter{Child: &Condition{Column: "city", Operator: Eq, Value: "Paris"}},
+	}}
+	rows, err := users.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(rows); !reflect.DeepEqual(got, []string{"Bob"}) {
+		t.Fatalf("got %v, want [Bob]", got)
+	}
+
+	// NOT of the whole AND
+	rows, err = users.Query(&NotFilter{Child: q})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(rows); !reflect.DeepEqual(got, []string{"Alice", "Carol", "Dave"}) {
+		t.Fatalf("got %v, want [Alice Carol Dave]", got)
+	}
+}
+
+func TestCompositeIndexFollowsUpdates(t *testing.T) {
+	users := newUsers("Alice", "Bob", "Alice")
+	for id, city := range map[int]string{1: "Paris", 2: "Paris", 3: "Rome"} {
+		users.Update(id, map[string]interface{}{"city": city})
+	}
+	users.CreateCompositeIndex([]string{"name", "city"})
+	columns := []string{"name", "city"}
+
+	lookup := func(name, city string) []int {
+		t.Helper()
+		ids, err := users.LookupComposite(columns, []interface{}{name, city})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+	if got := lookup("Alice", "Paris"); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("Alice in Paris = %v, want [1]", got)
+	}
+
+	users.Update(3, map[string]interface{}{"city": "Paris"})
+	users.Insert(map[string]interface{}{"name": "Alice", "city": "Rome"})
+	if got := lookup("Alice", "Paris"); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Fatalf("after moving row 3, Alice in Paris = %v, want [1 3]", got)
+	}
+	if got := lookup("Alice", "Rome"); !reflect.DeepEqual(got, []int{4}) {
+		t.Fatalf("Alice in Rome = %v, want [4]", got)
+	}
+	users.Delete(1)
+	if got := lookup("Alice", "Paris"); !reflect.DeepEqual(got, []int{3}) {
+		t.Fatalf("after delete, Alice in Paris = %v, want [3]", got)
+	}
+
+	if _, err := users.LookupComposite([]string{"city", "name"}, []interface{}{"Paris", "Alice"}); err == nil {
+		t.Fatal("lookup without a matching index succeeded, want an error")
+	}
+}
+
+func TestTableCountAndDrop(t *testing.T) {
+	users := newUsers("Alice", "Bob", "Carol")
+	users.Delete(2)
+	if err := users.Delete(2); err == nil {
+		t.Fatal("deleting twice succeeded, want an error")
+	}
+	if n := users.Count(); n != 2 {
+		t.Fatalf("count = %d, want 2", n)
+	}
+
+	db := NewDatabase("shop")
+	db.CreateTable("users", users.Schema)
+	db.CreateTable("orders", newOrders().Schema)
+	if got := db.ListTables(); !reflect.DeepEqual(got, []string{"orders", "users"}) {
+		t.Fatalf("tables = %v, want [orders users]", got)
+	}
+	if err := db.DropTable("orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DropTable("orders"); err == nil {
+		t.Fatal("dropping a missing table succeeded, want an error")
+	}
+	if got := db.ListTables(); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Fatalf("tables after drop = %v, want [users]", got)
+	}
+}
+
+func TestStringMatchingOperators(t *testing.T) {
+	users := newUsers("Alice", "Malik", "Bob")
+	for _, tc := range []struct {
+		op    Operator
+		value interface{}
+		want  []string
+	}{
+		{Like, "ali", []string{"Alice", "Malik"}},
+		{Like, "zed", nil},
+		{StartsWith, "AL", []string{"Alice"}},
+		{EndsWith, "IK", []string{"Malik"}},
+		{EndsWith, "x", nil},
+	} {
+		rows, err := users.Query(&Condition{Column: "name", Operator: tc.op, Value: tc.value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(rows); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("name %s %q = %v, want %v", tc.op, tc.value, got, tc.want)
+		}
+	}
+
+	// numbers never match the string operators
+	rows, err := users.Query(&Condition{Column: "age", Operator: Like, Value: 3})
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("age LIKE 3 = %v, %v, want no rows", rows, err)
+	}
+}
+
+func TestRejectedUpdateLeavesRowUnchanged(t *testing.T) {
+	users := newUsers("Alice")
+	users.CreateIndex("age")
+	users.CreateCompositeIndex([]string{"name", "age"})
+
+	err := users.Update(1, map[string]interface{}{"name": "Alicia", "age": 200})
+	if err == nil {
+		t.Fatal("update with age 200 succeeded, want a validation error")
+	}
+	row := users.Data[1]
+	if row["name"] != "Alice" || row["age"] != 30 || row[versionColumn] != 1 {
+		t.Fatalf("row = %v, want it unchanged", row)
+	}
+	if got := users.Indexes["age"].RangeLookup(200, 200); got != nil {
+		t.Fatalf("age index has %v at 200, want nothing", got)
+	}
+	if ids, _ := users.LookupComposite([]string{"name", "age"}, []interface{}{"Alice", 30}); !reflect.DeepEqual(ids, []int{1}) {
+		t.Fatalf("composite lookup = %v, want [1]", ids)
+	}
+}
+
+func TestInsertBatchAllOrNothing(t *testing.T) {
+	users := newUsers("Alice")
+	users.CreateIndex("name")
+
+	_, err := users.InsertBatch([]map[string]interface{}{
+		{"name": "Bob", "age": 25},
+		{"name": "Carol", "age": 40},
+		{"age": 50}, // no name
+	})
+	if err == nil {
+		t.Fatal("batch with an invalid row succeeded, want an error")
+	}
+	if n := users.Count(); n != 1 {
+		t.Fatalf("count = %d, want only Alice", n)
+	}
+	if _, ok := users.Indexes["name"].IndexMap["Bob"]; ok {
+		t.Fatal("Bob indexed from a rejected batch")
+	}
+
+	ids, err := users.InsertBatch([]map[string]interface{}{{"name": "Bob"}, {"name": "Carol"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{2, 3}) {
+		t.Fatalf("ids = %v, want [2 3] after the rejected batch", ids)
+	}
+	rows, err := users.Query(&Condition{Column: "name", Operator: Eq, Value: "Carol"})
+	if err != nil || len(rows) != 1 || rows[0]["id"] != 3 {
+		t.Fatalf("query Carol = %v, %v, want row 3", rows, err)
+	}
+}
+
+func TestNumericCoercionAndTypeMismatch(t *testing.T) {
+	users := newUsers("Alice", "Bob")
+	users.Update(2, map[string]interface{}{"age": 45})
+
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			users.CreateIndex("age")
+		}
+		rows, err := users.Query(&Condition{Column: "age", Operator: Gt, Value: 30.5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(rows); !reflect.DeepEqual(got, []string{"Bob"}) {
+			t.Fatalf("indexed %t: age > 30.5 = %v, want [Bob]", indexed, got)
+		}
+		rows, err = users.Query(&Condition{Column: "age", Operator: Eq, Value: 30.0})
+		if err != nil || len(rows) != 1 {
+			t.Fatalf("indexed %t: age == 30.0 = %v, %v, want Alice", indexed, rows, err)
+		}
+
+		_, err = users.Query(&Condition{Column: "age", Operator: Gt, Value: "30"})
+		if !errors.Is(err, ErrTypeMismatch) {
+			t.Fatalf("indexed %t: age > \"30\": got %v, want ErrTypeMismatch", indexed, err)
+		}
+	}
+}
+
+func TestIndexRemoveUnorderedKeys(t *testing.T) {
+	users := newUsers()
+	users.CreateIndex("score") // not in the schema, floats aren't ordered by the index
+	for _, score := range []float64{1.5, 2.5, 3.5} {
+		users.Insert(map[string]interface{}{"name": "u", "score": score})
+	}
+	users.Delete(1)
+	users.Delete(3)
+
+	idx := users.Indexes["score"]
+	if !reflect.DeepEqual(idx.SortedKeys, []interface{}{2.5}) {
+		t.Fatalf("sorted keys = %v, want only 2.5 left", idx.SortedKeys)
+	}
+	if len(idx.IndexMap) != 1 {
+		t.Fatalf("index map = %v, want only 2.5 left", idx.IndexMap)
+	}
+}
+
+// run with -race
+func TestCreateCompositeIndexWithWriters(t *testing.T) {
+	users := newUsers("Alice", "Bob")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			users.Insert(map[string]interface{}{"name": "Carol", "city": "Rome"})
+		}()
+		go func() {
+			defer wg.Done()
+			users.Update(1, map[string]interface{}{"city": fmt.Sprint("city", i)})
+		}()
+	}
+	users.CreateCompositeIndex([]string{"name", "city"})
+	wg.Wait()
+
+	ids, err := users.LookupComposite([]string{"name", "city"}, []interface{}{"Carol", "Rome"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 20 {
+		t.Fatalf("index has %d of the 20 Carols", len(ids))
+	}
+}
+
+func TestQueryHidesRowVersion(t *testing.T) {
+	users := newUsers("Alice")
+	users.Update(1, map[string]interface{}{"age": 31})
+
+	rows, err := users.Query(&Condition{Column: "name", Operator: Eq, Value: "Alice"})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("query = %v, %v, want Alice", rows, err)
+	}
+	if _, ok := rows[0][versionColumn]; ok {
+		t.Fatalf("row = %v, want the version hidden", rows[0])
+	}
+	if v, err := users.RowVersion(1); err != nil || v != 2 {
+		t.Fatalf("RowVersion = %d, %v, want 2", v, err)
+	}
+	if _, err := users.RowVersion(9); err == nil {
+		t.Fatal("RowVersion of a missing row succeeded, want an error")
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // --------- Cursor Struct ---------
@@ -12,8 +15,10 @@ type Cursor struct {
 
 // --------- Editor Struct ---------
 type Editor struct {
-	lines  []string
-	cursor Cursor
+	lines     []string
+	cursor    Cursor
+	anchor    *Cursor // selection start, the selection runs to the cursor
+	clipboard string
 }
 
 func NewEditor() *Editor {
@@ -40,6 +45,35 @@ func (e *Editor) Print() {
 	fmt.Println()
 }
 
+// PrintWithLineNumbers is Print with right-aligned 1-based line numbers
+func (e *Editor) PrintWithLineNumbers() {
+	width := len(strconv.Itoa(len(e.lines)))
+	for i, line := range e.lines {
+		prefix := fmt.Sprintf("%*d | ", width, i+1)
+		fmt.Println(prefix + line)
+		if i == e.cursor.line {
+			fmt.Println(strings.Repeat(" ", len(prefix)+e.cursor.col) + "^")
+		}
+	}
+	fmt.Println()
+}
+
+// --------- Document Stats ---------
+type Stats struct {
+	Lines      int
+	Words      int
+	Characters int // newlines between lines are not counted
+}
+
+func (e *Editor) Stats() Stats {
+	stats := Stats{Lines: len(e.lines)}
+	for _, line := range e.lines {
+		stats.Words += len(strings.Fields(line))
+		stats.Characters += utf8.RuneCountInString(line)
+	}
+	return stats
+}
+
 // --------- Command Interface ---------
 type Command interface {
 	Execute(e *Editor)
@@ -59,12 +93,29 @@ func (a *AppendCommand) Execute(e *Editor) {
 	e.cursor.col += len(a.text)
 }
 
+// --------- Insert At Command ---------
+// InsertAtCommand inserts Text at Line/Col, clamped to the document, and
+// leaves the cursor right after the inserted text
+type InsertAtCommand struct {
+	Line, Col int
+	Text      string
+}
+
+func (i *InsertAtCommand) Execute(e *Editor) {
+	line := min(max(i.Line, 0), len(e.lines)-1)
+	col := min(max(i.Col, 0), len(e.lines[line]))
+	e.cursor = Cursor{line: line, col: col}
+	e.anchor = nil
+	(&AppendCommand{text: i.Text}).Execute(e)
+}
+
 // --------- Replace Command ---------
 type ReplaceCommand struct {
 	text string
 }
 
 func (r *ReplaceCommand) Execute(e *Editor) {
+	e.anchor = nil // the selected text is gone
 	e.lines[e.cursor.line] = r.text
 	e.cursor.col = len(r.text)
 }
@@ -101,6 +152,147 @@ func (a *ArrowCommand) Execute(e *Editor) {
 	}
 }
 
+// --------- Home / End Commands ---------
+type HomeCommand struct{}
+
+func (h *HomeCommand) Execute(e *Editor) {
+	e.cursor.col = 0
+}
+
+type EndCommand struct{}
+
+func (c *EndCommand) Execute(e *Editor) {
+	e.cursor.col = len(e.lines[e.cursor.line])
+}
+
+// --------- Word Jump Command ---------
+// WordJumpCommand moves to the start of the next ("right") or previous ("left")
+// word on the current line. Spaces and punctuation both separate words.
+type WordJumpCommand struct {
+	direction string
+}
+
+func isWordChar(b byte) bool {
+	return b == '_' || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+func (w *WordJumpCommand) Execute(e *Editor) {
+	line := e.lines[e.cursor.line]
+	col := e.cursor.col
+	switch w.direction {
+	case "left":
+		for col > 0 && !isWordChar(line[col-1]) {
+			col--
+		}
+		for col > 0 && isWordChar(line[col-1]) {
+			col--
+		}
+	case "right":
+		for col < len(line) && isWordChar(line[col]) {
+			col++
+		}
+		for col < len(line) && !isWordChar(line[col]) {
+			col++
+		}
+	}
+	e.cursor.col = col
+}
+
+// --------- Selection / Clipboard Commands ---------
+// SelectCommand drops the selection anchor at the cursor, moving the cursor
+// afterwards extends the selection. Selections are limited to a single line.
+type SelectCommand struct{}
+
+func (s *SelectCommand) Execute(e *Editor) {
+	anchor := e.cursor
+	e.anchor = &anchor
+}
+
+// selection returns the selected column range on the cursor line,
+// clamped to the line in case it got shorter since the anchor was dropped
+func (e *Editor) selection() (from, to int, ok bool) {
+	if e.anchor == nil || e.anchor.line != e.cursor.line {
+		return 0, 0, false
+	}
+	from, to = e.anchor.col, e.cursor.col
+	if from > to {
+		from, to = to, from
+	}
+	to = min(to, len(e.lines[e.cursor.line]))
+	return from, to, from < to
+}
+
+type CopyCommand struct{}
+
+func (c *CopyCommand) Execute(e *Editor) {
+	if from, to, ok := e.selection(); ok {
+		e.clipboard = e.lines[e.cursor.line][from:to]
+	}
+}
+
+type CutCommand struct{}
+
+func (c *CutCommand) Execute(e *Editor) {
+	from, to, ok := e.selection()
+	if !ok {
+		return
+	}
+	line := e.lines[e.cursor.line]
+	e.clipboard = line[from:to]
+	e.lines[e.cursor.line] = line[:from] + line[to:]
+	e.cursor.col = from
+	e.anchor = nil
+}
+
+// PasteCommand inserts the clipboard at the cursor, a no-op when it's empty
+type PasteCommand struct{}
+
+func (p *PasteCommand) Execute(e *Editor) {
+	if e.clipboard == "" {
+		return
+	}
+	e.anchor = nil
+	(&AppendCommand{text: e.clipboard}).Execute(e)
+}
+
+// --------- Find Command ---------
+// FindCommand moves the cursor to the next occurrence of Term after the cursor,
+// wrapping around to the start of the document. Found reports whether it matched.
+type FindCommand struct {
+	Term  string
+	Found bool
+}
+
+func (f *FindCommand) Execute(e *Editor) {
+	f.Found = false
+	if f.Term == "" {
+		return
+	}
+	// one extra round to revisit the cursor line from its start after wrapping
+	for i := 0; i <= len(e.lines); i++ {
+		lineIdx := (e.cursor.line + i) % len(e.lines)
+		line := e.lines[lineIdx]
+		start := 0
+		if i == 0 {
+			start = e.cursor.col + 1
+		}
+		if start > len(line) {
+			continue
+		}
+		idx := strings.Index(line[start:], f.Term)
+		if idx < 0 {
+			continue
+		}
+		col := start + idx
+		if i == len(e.lines) && col > e.cursor.col {
+			break
+		}
+		e.cursor.line, e.cursor.col = lineIdx, col
+		f.Found = true
+		return
+	}
+}
+
 // --------- Page Command ---------
 type PageCommand struct {
 	up bool
@@ -170,4 +362,42 @@ func main() {
 	executor.ExecuteCommand(&ReplaceCommand{text: "Replaced Text"}, editor)
 
 	editor.Print()
+
+	// Word jumps stop at "bar" and "baz", then at the end of the line
+	executor.ExecuteCommand(&ReplaceCommand{text: "foo  bar.baz"}, editor)
+	executor.ExecuteCommand(&HomeCommand{}, editor)
+	executor.ExecuteCommand(&WordJumpCommand{direction: "right"}, editor)
+	executor.ExecuteCommand(&WordJumpCommand{direction: "right"}, editor)
+	editor.Print()
+	executor.ExecuteCommand(&EndCommand{}, editor)
+	executor.ExecuteCommand(&WordJumpCommand{direction: "left"}, editor)
+	executor.ExecuteCommand(&WordJumpCommand{direction: "left"}, editor)
+	editor.Print()
+
+	// Copy "World" from the first line and paste it at the start
+	executor.ExecuteCommand(&PageCommand{up: true}, editor)
+	executor.ExecuteCommand(&PageCommand{up: true}, editor)
+	executor.ExecuteCommand(&EndCommand{}, editor)
+	executor.ExecuteCommand(&SelectCommand{}, editor)
+	executor.ExecuteCommand(&WordJumpCommand{direction: "left"}, editor)
+	executor.ExecuteCommand(&CopyCommand{}, editor)
+	executor.ExecuteCommand(&HomeCommand{}, editor)
+	executor.ExecuteCommand(&PasteCommand{}, editor)
+	executor.ExecuteCommand(&AppendCommand{text: " "}, editor)
+	fmt.Println(editor.lines[0])
+
+	// Find the second "World", then wrap around to the first one
+	find := &FindCommand{Term: "World"}
+	executor.ExecuteCommand(&HomeCommand{}, editor)
+	executor.ExecuteCommand(find, editor)
+	fmt.Println("Found:", find.Found, "at", editor.cursor.line, editor.cursor.col)
+	executor.ExecuteCommand(find, editor)
+	fmt.Println("Found:", find.Found, "at", editor.cursor.line, editor.cursor.col)
+
+	executor.ExecuteCommand(&InsertAtCommand{Line: 1, Col: 4, Text: " number"}, editor)
+	executor.ExecuteCommand(&InsertAtCommand{Line: 99, Col: 99, Text: "!"}, editor)
+	editor.PrintWithLineNumbers()
+
+	stats := editor.Stats()
+	fmt.Printf("Lines: %d, Words: %d, Characters: %d\n", stats.Lines, stats.Words, stats.Characters)
 }
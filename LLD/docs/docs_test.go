@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newDoc returns an editor holding lines with the cursor at the start
+func newDoc(lines ...string) *Editor {
+	e := NewEditor()
+	e.lines = lines
+	return e
+}
+
+func TestStats(t *testing.T) {
+	e := newDoc("Hello world", "", "  Go is  fun ", "héllo")
+	want := Stats{Lines: 4, Words: 6, Characters: 11 + 0 + 13 + 5}
+	if got := e.Stats(); got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+}
+
+// run executes the commands in order
+func run(e *Editor, commands ...Command) {
+	executor := &CommandExecutor{}
+	for _, command := range commands {
+		executor.ExecuteCommand(command, e)
+	}
+}
+
+func TestWordJumpAndHomeEnd(t *testing.T) {
+	e := newDoc("foo  bar.baz")
+	var cols []int
+	for i := 0; i < 3; i++ {
+		run(e, &WordJumpCommand{direction: "right"})
+		cols = append(cols, e.cursor.col)
+	}
+	for i := 0; i < 3; i++ {
+		run(e, &WordJumpCommand{direction: "left"})
+		cols = append(cols, e.cursor.col)
+	}
+	want := []int{5, 9, 12, 9, 5, 0}
+	for i := range want {
+		if cols[i] != want[i] {
+			t.Fatalf("word jumps stopped at %v, want %v", cols, want)
+		}
+	}
+
+	run(e, &EndCommand{})
+	if e.cursor.col != 12 {
+		t.Fatalf("End moved to %d, want 12", e.cursor.col)
+	}
+	run(e, &HomeCommand{})
+	if e.cursor.col != 0 {
+		t.Fatalf("Home moved to %d, want 0", e.cursor.col)
+	}
+}
+
+func TestCopyPasteAndCut(t *testing.T) {
+	e := newDoc("Hello World")
+	run(e, &PasteCommand{}) // nothing copied yet
+	if e.lines[0] != "Hello World" || e.cursor.col != 0 {
+		t.Fatalf("paste with an empty clipboard changed %q, cursor %d", e.lines[0], e.cursor.col)
+	}
+
+	run(e, &EndCommand{}, &SelectCommand{}, &WordJumpCommand{direction: "left"}, &CopyCommand{})
+	if e.clipboard != "World" {
+		t.Fatalf("copied %q, want World", e.clipboard)
+	}
+	run(e, &HomeCommand{}, &PasteCommand{}, &AppendCommand{text: " "})
+	if e.lines[0] != "World Hello World" {
+		t.Fatalf("after paste got %q, want World Hello World", e.lines[0])
+	}
+
+	// cut "Hello " selecting right to left
+	e.cursor.col = 12
+	run(e, &SelectCommand{}, &WordJumpCommand{direction: "left"}, &CutCommand{})
+	if e.lines[0] != "World World" || e.clipboard != "Hello " || e.cursor.col != 6 {
+		t.Fatalf("after cut got %q with %q copied and cursor %d", e.lines[0], e.clipboard, e.cursor.col)
+	}
+}
+
+func TestFindNextWrapsAround(t *testing.T) {
+	e := newDoc("the cat", "a dog and a cat", "no match")
+	find := &FindCommand{Term: "cat"}
+	want := []Cursor{{0, 4}, {1, 12}, {0, 4}}
+	for i, pos := range want {
+		run(e, find)
+		if !find.Found || e.cursor != pos {
+			t.Fatalf("find %d: found %t at %+v, want %+v", i+1, find.Found, e.cursor, pos)
+		}
+	}
+
+	missing := &FindCommand{Term: "bird"}
+	run(e, missing)
+	if missing.Found || e.cursor != (Cursor{0, 4}) {
+		t.Fatalf("find bird: found %t at %+v, want no match and the cursor kept", missing.Found, e.cursor)
+	}
+
+	// the only match is under the cursor, wrapping comes back to it
+	e = newDoc("x", "one dog")
+	e.cursor = Cursor{1, 4}
+	dog := &FindCommand{Term: "dog"}
+	run(e, dog)
+	if !dog.Found || e.cursor != (Cursor{1, 4}) {
+		t.Fatalf("find dog: found %t at %+v, want the match under the cursor", dog.Found, e.cursor)
+	}
+}
+
+// captureOutput returns what fn prints to stdout
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrintWithLineNumbersAligns(t *testing.T) {
+	e := newDoc()
+	for i := 1; i <= 12; i++ {
+		e.lines = append(e.lines, fmt.Sprintf("line %d", i))
+	}
+	e.cursor = Cursor{line: 9, col: 2}
+
+	lines := strings.Split(captureOutput(t, e.PrintWithLineNumbers), "\n")
+	if lines[0] != " 1 | line 1" {
+		t.Fatalf("first line = %q, want a padded number", lines[0])
+	}
+	if lines[9] != "10 | line 10" || lines[10] != "       ^" {
+		t.Fatalf("line 10 printed as %q with cursor %q, want the cursor under column 2", lines[9], lines[10])
+	}
+	if lines[12] != "12 | line 12" {
+		t.Fatalf("last line = %q", lines[12])
+	}
+}
+
+func TestInsertAtClampsPosition(t *testing.T) {
+	e := newDoc("first line", "second line", "third")
+	run(e, &InsertAtCommand{Line: 1, Col: 6, Text: " inserted"})
+	if e.lines[1] != "second inserted line" {
+		t.Fatalf("line 2 = %q, want second inserted line", e.lines[1])
+	}
+	if e.cursor != (Cursor{1, 15}) {
+		t.Fatalf("cursor = %+v, want right after the inserted text", e.cursor)
+	}
+
+	run(e, &InsertAtCommand{Line: 99, Col: 99, Text: "!"})
+	run(e, &InsertAtCommand{Line: -1, Col: -5, Text: "> "})
+	want := []string{"> first line", "second inserted line", "third!"}
+	for i := range want {
+		if e.lines[i] != want[i] {
+			t.Fatalf("lines = %q, want %q", e.lines, want)
+		}
+	}
+}
+
+func TestSelectionAfterLineChanges(t *testing.T) {
+	e := newDoc("")
+	run(e, &AppendCommand{text: "Hello World"}, &SelectCommand{}, &ReplaceCommand{text: "Hi"}, &CopyCommand{}, &CutCommand{})
+	if e.lines[0] != "Hi" || e.clipboard != "" {
+		t.Fatalf("after replacing the selection got %q with %q copied, want Hi and nothing copied", e.lines[0], e.clipboard)
+	}
+
+	// a line shortened under a kept anchor only selects what's left
+	e = newDoc("Hello World")
+	e.cursor.col = 11
+	run(e, &SelectCommand{}, &HomeCommand{})
+	e.lines[0] = "Hey"
+	run(e, &CutCommand{})
+	if e.lines[0] != "" || e.clipboard != "Hey" {
+		t.Fatalf("after cut got %q with %q copied, want the whole shorter line cut", e.lines[0], e.clipboard)
+	}
+}
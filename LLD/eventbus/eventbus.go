@@ -0,0 +1,83 @@
+package eventbus
+
+import "sync"
+
+// Mode decides how Publish runs the handlers
+type Mode int
+
+const (
+	Sync  Mode = iota // handlers run in subscribe order before Publish returns
+	Async             // each handler runs on its own goroutine
+)
+
+type Handler func(payload any)
+
+// Bus delivers published payloads to every handler subscribed to the topic,
+// it is safe for concurrent use
+type Bus struct {
+	mu       sync.RWMutex
+	mode     Mode
+	handlers map[string][]Handler
+
+	// async handlers still running, counted under a mutex rather than a
+	// WaitGroup so Publish may run while another goroutine is in Wait
+	pendingMu sync.Mutex
+	pending   int
+	idle      *sync.Cond
+}
+
+func New(mode Mode) *Bus {
+	b := &Bus{mode: mode, handlers: make(map[string][]Handler)}
+	b.idle = sync.NewCond(&b.pendingMu)
+	return b
+}
+
+func (b *Bus) Subscribe(topic string, handler func(any)) {
+	if handler == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish sends the payload to the topic's handlers, a topic with no
+// subscribers drops it
+func (b *Bus) Publish(topic string, payload any) {
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers[topic]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if b.mode == Sync {
+			handler(payload)
+			continue
+		}
+		b.pendingMu.Lock()
+		b.pending++
+		b.pendingMu.Unlock()
+		go func(handler Handler) {
+			defer b.done()
+			handler(payload)
+		}(handler)
+	}
+}
+
+func (b *Bus) done() {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	b.pending--
+	if b.pending == 0 {
+		b.idle.Broadcast()
+	}
+}
+
+// Wait blocks until no async handler is running, including handlers
+// started by Publish calls made while waiting
+func (b *Bus) Wait() {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	for b.pending > 0 {
+		b.idle.Wait()
+	}
+}
@@ -0,0 +1,92 @@
+package eventbus
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSyncDeliversBeforePublishReturns(t *testing.T) {
+	bus := New(Sync)
+	var got []any
+	bus.Subscribe("stock.low", func(payload any) {
+		got = append(got, payload)
+	})
+
+	bus.Publish("stock.low", "cola")
+	bus.Publish("stock.low", "chips")
+	bus.Publish("stock.empty", "water") // no subscribers
+
+	if want := []any{"cola", "chips"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSyncRunsHandlersInSubscribeOrder(t *testing.T) {
+	bus := New(Sync)
+	var order []int
+	for i := 1; i <= 3; i++ {
+		bus.Subscribe("topic", func(any) { order = append(order, i) })
+	}
+	bus.Subscribe("topic", nil) // ignored
+
+	bus.Publish("topic", nil)
+
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestAsyncDeliversToEveryHandler(t *testing.T) {
+	bus := New(Async)
+	var mu sync.Mutex
+	counts := map[string]int{}
+	for _, name := range []string{"a", "b", "c"} {
+		bus.Subscribe("evicted", func(payload any) {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[name] += payload.(int)
+		})
+	}
+
+	for i := 1; i <= 10; i++ {
+		bus.Publish("evicted", i)
+	}
+	bus.Wait()
+
+	if want := map[string]int{"a": 55, "b": 55, "c": 55}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("got %v, want %v", counts, want)
+	}
+}
+
+func TestAsyncPublishDuringWait(t *testing.T) {
+	bus := New(Async)
+	var mu sync.Mutex
+	delivered := 0
+	bus.Subscribe("topic", func(any) {
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				bus.Publish("topic", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			bus.Wait()
+		}()
+	}
+	wg.Wait()
+	bus.Wait()
+
+	if delivered != 400 {
+		t.Fatalf("delivered %d payloads, want 400", delivered)
+	}
+}
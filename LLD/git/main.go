@@ -64,23 +64,54 @@ type ICommand interface {
 	Execute()
 }
 
+// IUndoableCommand is a command that can reverse its last Execute.
+// VersionControl keeps these so UndoLast can step back.
+type IUndoableCommand interface {
+	ICommand
+	Undo() error
+}
+
 // AddFileCommand adds a file to the staging area.
 type AddFileCommand struct {
 	vc   *VersionControl
 	file File
+
+	// staged content replaced by Execute, restored by Undo
+	prevContent string
+	wasStaged   bool
 }
 
 func (cmd *AddFileCommand) Execute() {
+	cmd.prevContent, cmd.wasStaged = cmd.vc.stagingArea[cmd.file.Name]
 	cmd.vc.stagingArea[cmd.file.Name] = cmd.file.Content
 }
 
+func (cmd *AddFileCommand) Undo() error {
+	if cmd.wasStaged {
+		cmd.vc.stagingArea[cmd.file.Name] = cmd.prevContent
+		return nil
+	}
+	delete(cmd.vc.stagingArea, cmd.file.Name)
+	return nil
+}
+
 // CommitCommand handles committing staged files.
 type CommitCommand struct {
 	vc      *VersionControl
 	message string
+
+	// state before Execute, restored by Undo
+	branch      IBranch
+	commit      ICommit
+	prevHead    ICommit
+	prevStaging map[string]string
 }
 
 func (cmd *CommitCommand) Execute() {
+	cmd.branch = cmd.vc.current
+	cmd.prevHead = cmd.vc.current.GetHead()
+	cmd.prevStaging = cmd.vc.stagingArea
+
 	files := make(map[string]string)
 	head := cmd.vc.current.GetHead()
 	if head != nil {
@@ -102,6 +133,53 @@ func (cmd *CommitCommand) Execute() {
 	cmd.vc.current.AddCommit(commit)
 	cmd.vc.commitID++
 	cmd.vc.stagingArea = make(map[string]string)
+	cmd.commit = commit
+}
+
+// Undo drops the commit and restores HEAD and the staged files. It fails once
+// HEAD has moved past the commit, eg. a later revert, reset or rollback.
+// The commit ID isn't reused, it may already be tagged or cherry-picked.
+func (cmd *CommitCommand) Undo() error {
+	if cmd.branch.GetHead() != cmd.commit {
+		return fmt.Errorf("HEAD of %s moved past commit %d", cmd.branch.GetName(), cmd.commit.GetID())
+	}
+	var commits []ICommit
+	for _, c := range cmd.branch.GetCommits() {
+		if c != cmd.commit {
+			commits = append(commits, c)
+		}
+	}
+	cmd.branch.SetHead(cmd.prevHead)
+	cmd.branch.SetCommits(commits)
+	// files staged since the commit win over the ones it took
+	for name, content := range cmd.prevStaging {
+		if _, staged := cmd.vc.stagingArea[name]; !staged {
+			cmd.vc.stagingArea[name] = content
+		}
+	}
+	return nil
+}
+
+// CreateBranchCommand creates a branch from the current one.
+type CreateBranchCommand struct {
+	vc   *VersionControl
+	name string
+
+	prevBranch IBranch // branch of the same name that Execute replaced, if any
+}
+
+func (cmd *CreateBranchCommand) Execute() {
+	cmd.prevBranch = cmd.vc.branches[cmd.name]
+	cmd.vc.CreateBranch(cmd.name)
+}
+
+func (cmd *CreateBranchCommand) Undo() error {
+	if cmd.prevBranch != nil {
+		cmd.vc.branches[cmd.name] = cmd.prevBranch
+		return nil
+	}
+	delete(cmd.vc.branches, cmd.name)
+	return nil
 }
 
 // RollbackCommand handles rollback functionality.
@@ -114,6 +192,7 @@ func (cmd *RollbackCommand) Execute() {
 	commits := cmd.vc.current.GetCommits()
 	for i := len(commits) - 1; i >= 0; i-- {
 		if commits[i].GetID() == cmd.commitID {
+			cmd.vc.recordRollback(cmd.vc.current, commits[i])
 			cmd.vc.current.SetHead(commits[i])
 			cmd.vc.current.SetCommits(commits[:i+1])
 			fmt.Println("Rolled back to commit", cmd.commitID)
@@ -156,12 +235,122 @@ func (cmd *RevertCommand) Execute() {
 	fmt.Println("Reverted to commit", cmd.commitID)
 }
 
+// ResetCommand moves HEAD to a commit. A "soft" reset keeps the staging area,
+// a "hard" reset clears it and discards the commits after the target.
+type ResetCommand struct {
+	vc       *VersionControl
+	commitID int
+	mode     string
+}
+
+func (cmd *ResetCommand) Execute() {
+	if cmd.mode != "soft" && cmd.mode != "hard" {
+		fmt.Println("Invalid reset mode", cmd.mode)
+		return
+	}
+	commits := cmd.vc.current.GetCommits()
+	for i := len(commits) - 1; i >= 0; i-- {
+		if commits[i].GetID() == cmd.commitID {
+			cmd.vc.current.SetHead(commits[i])
+			if cmd.mode == "hard" {
+				cmd.vc.current.SetCommits(commits[:i+1])
+				cmd.vc.stagingArea = make(map[string]string)
+			}
+			fmt.Printf("Reset (%s) to commit %d\n", cmd.mode, cmd.commitID)
+			return
+		}
+	}
+	fmt.Println("Commit ID not found")
+}
+
+// CherryPickCommand applies the changes of a single commit from another branch
+// as a new commit on the current branch.
+type CherryPickCommand struct {
+	vc           *VersionControl
+	sourceBranch string
+	commitID     int
+}
+
+func (cmd *CherryPickCommand) Execute() {
+	branch, ok := cmd.vc.branches[cmd.sourceBranch]
+	if !ok {
+		fmt.Println("Branch does not exist")
+		return
+	}
+	var source ICommit
+	for _, c := range branch.GetCommits() {
+		if c.GetID() == cmd.commitID {
+			source = c
+			break
+		}
+	}
+	if source == nil {
+		fmt.Println("Commit ID not found")
+		return
+	}
+
+	// diff the picked commit against its parent
+	parentFiles := map[string]string{}
+	if source.GetParent() != nil {
+		parentFiles = source.GetParent().GetFiles()
+	}
+
+	files := make(map[string]string)
+	head := cmd.vc.current.GetHead()
+	if head != nil {
+		for k, v := range head.GetFiles() {
+			files[k] = v
+		}
+	}
+	for name, content := range source.GetFiles() {
+		if old, exists := parentFiles[name]; !exists || old != content {
+			// files missing on the current branch are simply added
+			files[name] = content
+		}
+	}
+	for name := range parentFiles {
+		if _, exists := source.GetFiles()[name]; !exists {
+			delete(files, name)
+		}
+	}
+
+	commit := &Commit{
+		id:        cmd.vc.commitID,
+		files:     files,
+		message:   fmt.Sprintf("Cherry-pick commit %d from %s: %s", cmd.commitID, cmd.sourceBranch, source.GetMessage()),
+		timestamp: time.Now(),
+		parent:    head,
+	}
+	cmd.vc.current.SetHead(commit)
+	cmd.vc.current.AddCommit(commit)
+	cmd.vc.commitID++
+	fmt.Println("Cherry-picked commit", cmd.commitID)
+}
+
+// reflogSize is how many rollbacks UndoRollback can step back through.
+const reflogSize = 8
+
+// reflogEntry is a branch as it was before a rollback discarded commits.
+type reflogEntry struct {
+	branch       IBranch
+	head         ICommit
+	commits      []ICommit
+	rolledBackTo ICommit
+}
+
 // VersionControl orchestrates version control features.
 type VersionControl struct {
 	branches    map[string]IBranch
 	current     IBranch
 	stagingArea map[string]string
+	tags        map[string]ICommit
 	commitID    int
+	history     []IUndoableCommand // executed commands that can be undone, latest last
+
+	// ring buffer of the latest rollbacks, oldest are overwritten
+	reflog     [reflogSize]reflogEntry
+	reflogNext int
+	reflogLen  int
 }
 
 func NewVersionControl() *VersionControl {
@@ -170,6 +359,7 @@ func NewVersionControl() *VersionControl {
 		branches:    map[string]IBranch{"master": master},
 		current:     master,
 		stagingArea: make(map[string]string),
+		tags:        make(map[string]ICommit),
 		commitID:    0,
 	}
 	return vc
@@ -177,6 +367,56 @@ func NewVersionControl() *VersionControl {
 
 func (vc *VersionControl) RunCommand(cmd ICommand) {
 	cmd.Execute()
+	if undoable, ok := cmd.(IUndoableCommand); ok {
+		vc.history = append(vc.history, undoable)
+	}
+}
+
+// recordRollback saves the branch before it's rolled back to target.
+func (vc *VersionControl) recordRollback(branch IBranch, target ICommit) {
+	vc.reflog[vc.reflogNext] = reflogEntry{
+		branch:       branch,
+		head:         branch.GetHead(),
+		commits:      append([]ICommit{}, branch.GetCommits()...),
+		rolledBackTo: target,
+	}
+	vc.reflogNext = (vc.reflogNext + 1) % reflogSize
+	if vc.reflogLen < reflogSize {
+		vc.reflogLen++
+	}
+}
+
+// UndoRollback restores the commits discarded by the latest rollback. It fails
+// once the branch has moved on, or after more than reflogSize rollbacks.
+func (vc *VersionControl) UndoRollback() error {
+	if vc.reflogLen == 0 {
+		return fmt.Errorf("no rollback to undo")
+	}
+	last := (vc.reflogNext - 1 + reflogSize) % reflogSize
+	entry := vc.reflog[last]
+	if entry.branch.GetHead() != entry.rolledBackTo {
+		return fmt.Errorf("branch %s moved since the rollback", entry.branch.GetName())
+	}
+	entry.branch.SetHead(entry.head)
+	entry.branch.SetCommits(entry.commits)
+	vc.reflog[last] = reflogEntry{}
+	vc.reflogNext = last
+	vc.reflogLen--
+	return nil
+}
+
+// UndoLast reverses the most recent undoable command. A command that can't be
+// undone any more stays on the history.
+func (vc *VersionControl) UndoLast() error {
+	if len(vc.history) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	last := vc.history[len(vc.history)-1]
+	if err := last.Undo(); err != nil {
+		return err
+	}
+	vc.history = vc.history[:len(vc.history)-1]
+	return nil
 }
 
 func (vc *VersionControl) CreateBranch(name string) {
@@ -196,23 +436,101 @@ func (vc *VersionControl) CheckoutBranch(name string) {
 	}
 }
 
+// findCommit looks up a commit by ID across all the branches.
+func (vc *VersionControl) findCommit(commitID int) ICommit {
+	for _, branch := range vc.branches {
+		for _, c := range branch.GetCommits() {
+			if c.GetID() == commitID {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// CreateTag marks a commit with an immutable name, eg. a release.
+func (vc *VersionControl) CreateTag(name string, commitID int) error {
+	if _, exists := vc.tags[name]; exists {
+		return fmt.Errorf("tag %q already exists", name)
+	}
+	commit := vc.findCommit(commitID)
+	if commit == nil {
+		return fmt.Errorf("commit %d not found", commitID)
+	}
+	vc.tags[name] = commit
+	return nil
+}
+
+// CheckoutTag checks out the tagged commit on a detached branch named after the tag.
+func (vc *VersionControl) CheckoutTag(name string) {
+	commit, ok := vc.tags[name]
+	if !ok {
+		fmt.Println("Tag does not exist")
+		return
+	}
+	var history []ICommit
+	for c := commit; c != nil; c = c.GetParent() {
+		history = append([]ICommit{c}, history...)
+	}
+	vc.current = &Branch{name: name, head: commit, commitList: history}
+}
+
 func main() {
 	vc := NewVersionControl()
 
-	vc.RunCommand(&AddFileCommand{vc, File{"file1.txt", "Hello World"}})
-	vc.RunCommand(&CommitCommand{vc, "Initial commit"})
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"file1.txt", "Hello World"}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: "Initial commit"})
 
-	vc.RunCommand(&AddFileCommand{vc, File{"file2.txt", "Another file"}})
-	vc.RunCommand(&CommitCommand{vc, "Added file2"})
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"file2.txt", "Another file"}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: "Added file2"})
 
 	vc.CreateBranch("feature")
 	vc.CheckoutBranch("feature")
 
-	vc.RunCommand(&AddFileCommand{vc, File{"file1.txt", "Updated in feature"}})
-	vc.RunCommand(&CommitCommand{vc, "Updated file1 in feature branch"})
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"file1.txt", "Updated in feature"}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: "Updated file1 in feature branch"})
 
 	vc.RunCommand(&RevertCommand{vc, 0}) // Revert to first commit while preserving history
 
 	fmt.Println("Current HEAD ID:", vc.current.GetHead().GetID())
 	fmt.Println("Current HEAD files:", vc.current.GetHead().GetFiles())
+
+	vc.CheckoutBranch("master")
+	vc.RunCommand(&CherryPickCommand{vc, "feature", 2}) // Bring the feature update onto master
+
+	fmt.Println("Master HEAD message:", vc.current.GetHead().GetMessage())
+	fmt.Println("Master HEAD files:", vc.current.GetHead().GetFiles())
+
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"file3.txt", "Staged file"}})
+	vc.RunCommand(&ResetCommand{vc, 1, "soft"}) // HEAD moves, file3.txt stays staged
+	fmt.Println("Staged after soft reset:", vc.stagingArea)
+	vc.RunCommand(&ResetCommand{vc, 1, "hard"}) // Staging cleared, later commits dropped
+	fmt.Println("Staged after hard reset:", vc.stagingArea)
+	fmt.Println("Master commits after hard reset:", len(vc.current.GetCommits()))
+
+	if err := vc.CreateTag("v1.0", 1); err != nil {
+		fmt.Println(err)
+	}
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"file4.txt", "After release"}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: "Post release work"})
+	vc.CheckoutTag("v1.0")
+	fmt.Println("Tag v1.0 HEAD ID:", vc.current.GetHead().GetID())
+
+	// Undo the latest commit, HEAD goes back and the file is staged again
+	vc.CheckoutBranch("master")
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"file5.txt", "Oops"}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: "Committed too early"})
+	fmt.Println("HEAD before undo:", vc.current.GetHead().GetID())
+	if err := vc.UndoLast(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("HEAD after undo:", vc.current.GetHead().GetID(), "staged:", vc.stagingArea)
+
+	// A rollback can be undone, bringing the discarded commits back
+	vc.RunCommand(&RollbackCommand{vc, 0})
+	fmt.Println("Master commits after rollback:", len(vc.current.GetCommits()))
+	if err := vc.UndoRollback(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("Master commits after undoing it:", len(vc.current.GetCommits()), "HEAD:", vc.current.GetHead().GetID())
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// commitFile stages a file and commits it on the current branch
+func commitFile(vc *VersionControl, name, content, message string) {
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{name, content}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: message})
+}
+
+func TestUndoLastCommit(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"b.txt", "B"}})
+	vc.RunCommand(&CommitCommand{vc: vc, message: "second"})
+
+	if err := vc.UndoLast(); err != nil {
+		t.Fatal(err)
+	}
+	if head := vc.current.GetHead(); head.GetID() != 0 {
+		t.Fatalf("HEAD = %d, want 0", head.GetID())
+	}
+	if n := len(vc.current.GetCommits()); n != 1 {
+		t.Fatalf("%d commits, want 1", n)
+	}
+	if vc.stagingArea["b.txt"] != "B" {
+		t.Fatalf("staging = %v, want b.txt staged again", vc.stagingArea)
+	}
+
+	// undoing the add unstages the file
+	if err := vc.UndoLast(); err != nil {
+		t.Fatal(err)
+	}
+	if len(vc.stagingArea) != 0 {
+		t.Fatalf("staging = %v, want empty", vc.stagingArea)
+	}
+}
+
+func TestUndoCommitKeepsCommitIDs(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	commitFile(vc, "b.txt", "B", "second")
+	if err := vc.CreateTag("v2", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := vc.UndoLast(); err != nil {
+		t.Fatal(err)
+	}
+
+	vc.RunCommand(&CommitCommand{vc: vc, message: "again"})
+	if id := vc.current.GetHead().GetID(); id != 2 {
+		t.Fatalf("new commit ID = %d, want 2", id)
+	}
+	if vc.tags["v2"].GetMessage() != "second" {
+		t.Fatalf("tag v2 points at %q, want second", vc.tags["v2"].GetMessage())
+	}
+}
+
+func TestUndoCommitRefusedOnceHeadMoved(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	commitFile(vc, "a.txt", "AA", "second")
+	vc.RunCommand(&RevertCommand{vc, 0})
+
+	if err := vc.UndoLast(); err == nil {
+		t.Fatal("undo after a revert succeeded, want an error")
+	}
+	head := vc.current.GetHead()
+	if head.GetID() != 2 || len(vc.current.GetCommits()) != 3 {
+		t.Fatalf("HEAD = %d with %d commits, want the revert kept", head.GetID(), len(vc.current.GetCommits()))
+	}
+}
+
+func TestUndoCreateBranch(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	vc.RunCommand(&CreateBranchCommand{vc: vc, name: "feature"})
+	if _, ok := vc.branches["feature"]; !ok {
+		t.Fatal("feature branch not created")
+	}
+	if err := vc.UndoLast(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := vc.branches["feature"]; ok {
+		t.Fatal("feature branch still exists after undo")
+	}
+}
+
+func TestCherryPickFeatureCommitOntoMaster(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "base")
+	vc.CreateBranch("feature")
+	vc.CheckoutBranch("feature")
+	commitFile(vc, "a.txt", "A2", "change a")
+	commitFile(vc, "feature.txt", "F", "add feature")
+	vc.CheckoutBranch("master")
+	commitFile(vc, "master.txt", "M", "master work")
+
+	vc.RunCommand(&CherryPickCommand{vc: vc, sourceBranch: "feature", commitID: 2})
+
+	head := vc.current.GetHead()
+	want := map[string]string{"a.txt": "A", "master.txt": "M", "feature.txt": "F"}
+	if fmt.Sprint(head.GetFiles()) != fmt.Sprint(want) {
+		t.Fatalf("files = %v, want %v", head.GetFiles(), want)
+	}
+	if head.GetParent().GetMessage() != "master work" {
+		t.Fatalf("cherry-pick parent = %q, want master work", head.GetParent().GetMessage())
+	}
+	if !strings.Contains(head.GetMessage(), "add feature") || !strings.Contains(head.GetMessage(), "feature") {
+		t.Fatalf("message = %q, want it to name the picked commit", head.GetMessage())
+	}
+	if n := len(vc.branches["feature"].GetCommits()); n != 3 {
+		t.Fatalf("feature has %d commits, want it untouched with 3", n)
+	}
+}
+
+func TestSoftResetKeepsStaging(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	commitFile(vc, "b.txt", "B", "second")
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"c.txt", "C"}})
+
+	vc.RunCommand(&ResetCommand{vc: vc, commitID: 0, mode: "soft"})
+	if id := vc.current.GetHead().GetID(); id != 0 {
+		t.Fatalf("HEAD = %d, want 0", id)
+	}
+	if vc.stagingArea["c.txt"] != "C" {
+		t.Fatalf("staging = %v, want c.txt still staged", vc.stagingArea)
+	}
+	if n := len(vc.current.GetCommits()); n != 2 {
+		t.Fatalf("%d commits, want both kept by a soft reset", n)
+	}
+}
+
+func TestHardResetClearsStaging(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	commitFile(vc, "b.txt", "B", "second")
+	vc.RunCommand(&AddFileCommand{vc: vc, file: File{"c.txt", "C"}})
+
+	vc.RunCommand(&ResetCommand{vc: vc, commitID: 0, mode: "hard"})
+	if id := vc.current.GetHead().GetID(); id != 0 {
+		t.Fatalf("HEAD = %d, want 0", id)
+	}
+	if len(vc.stagingArea) != 0 {
+		t.Fatalf("staging = %v, want empty", vc.stagingArea)
+	}
+	if n := len(vc.current.GetCommits()); n != 1 {
+		t.Fatalf("%d commits, want the later one discarded", n)
+	}
+
+	vc.RunCommand(&ResetCommand{vc: vc, commitID: 0, mode: "mixed"})
+	if id := vc.current.GetHead().GetID(); id != 0 {
+		t.Fatalf("invalid mode moved HEAD to %d", id)
+	}
+}
+
+func TestTagKeepsPointingAtCommit(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "v1", "release")
+	if err := vc.CreateTag("v1.0", 0); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(vc, "a.txt", "v2", "more work")
+	commitFile(vc, "b.txt", "B", "even more")
+
+	if err := vc.CreateTag("v1.0", 2); err == nil {
+		t.Fatal("duplicate tag created, want an error")
+	}
+	if err := vc.CreateTag("v9", 42); err == nil {
+		t.Fatal("tag for a missing commit created, want an error")
+	}
+	if tagged := vc.tags["v1.0"]; tagged.GetID() != 0 || tagged.GetFiles()["a.txt"] != "v1" {
+		t.Fatalf("v1.0 points at commit %d, want 0", tagged.GetID())
+	}
+
+	vc.CheckoutTag("v1.0")
+	if head := vc.current.GetHead(); head.GetID() != 0 || len(vc.current.GetCommits()) != 1 {
+		t.Fatalf("checked out commit %d with %d commits, want commit 0 alone", head.GetID(), len(vc.current.GetCommits()))
+	}
+	if n := len(vc.branches["master"].GetCommits()); n != 3 {
+		t.Fatalf("master has %d commits after checking out a tag, want 3", n)
+	}
+}
+
+func TestUndoRollbackRestoresCommits(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	commitFile(vc, "b.txt", "B", "second")
+	commitFile(vc, "c.txt", "C", "third")
+
+	vc.RunCommand(&RollbackCommand{vc: vc, commitID: 0})
+	if n := len(vc.current.GetCommits()); n != 1 {
+		t.Fatalf("%d commits after rollback, want 1", n)
+	}
+	if err := vc.UndoRollback(); err != nil {
+		t.Fatal(err)
+	}
+	if head := vc.current.GetHead(); head.GetID() != 2 || len(vc.current.GetCommits()) != 3 {
+		t.Fatalf("HEAD = %d with %d commits, want the discarded commits back", head.GetID(), len(vc.current.GetCommits()))
+	}
+	if err := vc.UndoRollback(); err == nil {
+		t.Fatal("second undo succeeded, want an error")
+	}
+}
+
+func TestUndoRollbackRefusedAfterNewCommit(t *testing.T) {
+	vc := NewVersionControl()
+	commitFile(vc, "a.txt", "A", "first")
+	commitFile(vc, "b.txt", "B", "second")
+	vc.RunCommand(&RollbackCommand{vc: vc, commitID: 0})
+	commitFile(vc, "c.txt", "C", "new work")
+
+	if err := vc.UndoRollback(); err == nil {
+		t.Fatal("undo after a new commit succeeded, want an error")
+	}
+	if head := vc.current.GetHead(); head.GetMessage() != "new work" {
+		t.Fatalf("HEAD = %q, want the new commit kept", head.GetMessage())
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type IParkingService interface {
 	ParkVehicle(vehicle Vehicle) error
@@ -18,8 +21,12 @@ type VehicleType int
 const (
 	Car VehicleType = iota
 	Bike
+	Electric
 )
 
+// evChargingSurcharge is added on top of the car fee for electric vehicles
+const evChargingSurcharge = 5
+
 type IparkingRepo interface {
 	UpdateSpot(spotId *ParkingSpot) error
 	GetSpots() []*ParkingSpot
@@ -40,18 +47,23 @@ func (p *ParkingRepo) UpdateSpot(spotId *ParkingSpot) error {
 	return nil
 }
 
+// GetSpots returns the spots ordered by ID, so strategies always pick the
+// lowest free ID first
 func (p *ParkingRepo) GetSpots() []*ParkingSpot {
 	spots := make([]*ParkingSpot, 0, len(p.parkingSpots))
 	for _, spot := range p.parkingSpots {
 		spots = append(spots, spot)
 	}
+	sort.Slice(spots, func(i, j int) bool { return spots[i].ID < spots[j].ID })
 	return spots
 }
 
 type ParkingSpot struct {
-	ID     int
-	Level  int
-	status bool
+	ID          int
+	Level       int
+	HasCharger  bool // EV charging point, regular cars can still use it
+	status      bool
+	numberPlate string // plate of the parked vehicle, empty when free
 }
 
 type IPaymentService interface {
@@ -78,6 +90,9 @@ type ParkingService struct {
 }
 
 func (p *ParkingService) ParkVehicle(vehicle Vehicle) error {
+	if spot := p.findParked(vehicle.NumberPlate); spot != nil {
+		return fmt.Errorf("vehicle %s already parked in spot %d", vehicle.NumberPlate, spot.ID)
+	}
 	getParkingStategy := p.getParkingStrategy(vehicle)
 	if getParkingStategy == nil {
 		return fmt.Errorf("no parking strategy found for vehicle type")
@@ -89,14 +104,27 @@ func (p *ParkingService) ParkVehicle(vehicle Vehicle) error {
 	return nil
 }
 
+// UnParkVehicle frees the spot holding the vehicle's plate
 func (p *ParkingService) UnParkVehicle(vehicle Vehicle) error {
-	getParkingStategy := p.getParkingStrategy(vehicle)
-	if getParkingStategy == nil {
-		return fmt.Errorf("no parking strategy found for vehicle type")
+	spot := p.findParked(vehicle.NumberPlate)
+	if spot == nil {
+		return fmt.Errorf("vehicle %s is not parked", vehicle.NumberPlate)
 	}
-	err := getParkingStategy.ParkVehicle(vehicle)
-	if err != nil {
-		return fmt.Errorf("error parking vehicle: %v", err)
+	spot.status = false
+	spot.numberPlate = ""
+	if err := p.parkingRepo.UpdateSpot(spot); err != nil {
+		return fmt.Errorf("failed to update parking spot: %v", err)
+	}
+	fmt.Printf("Vehicle %s left spot %d\n", vehicle.NumberPlate, spot.ID)
+	return nil
+}
+
+// findParked returns the spot holding the plate, nil if it isn't parked
+func (p *ParkingService) findParked(numberPlate string) *ParkingSpot {
+	for _, spot := range p.parkingRepo.GetSpots() {
+		if spot.status && spot.numberPlate == numberPlate {
+			return spot
+		}
 	}
 	return nil
 }
@@ -107,6 +135,8 @@ func getFeesStrategy(vehicle Vehicle) (int, error) {
 		return 10, nil
 	case Bike:
 		return 5, nil
+	case Electric:
+		return 10 + evChargingSurcharge, nil
 	default:
 		return 0, fmt.Errorf("unknown vehicle type")
 	}
@@ -115,9 +145,11 @@ func getFeesStrategy(vehicle Vehicle) (int, error) {
 func (p *ParkingService) getParkingStrategy(vehicle Vehicle) IParkingStrategy {
 	switch vehicle.Type {
 	case Car:
-		return &CarParkingStrategy{}
+		return &CarParkingStrategy{ParkingRepo: p.parkingRepo}
 	case Bike:
-		return &BikeParkingStrategy{}
+		return &BikeParkingStrategy{ParkingRepo: p.parkingRepo}
+	case Electric:
+		return &ElectricParkingStrategy{ParkingRepo: p.parkingRepo}
 	}
 	return nil
 }
@@ -134,6 +166,7 @@ func (c *CarParkingStrategy) ParkVehicle(vehicle Vehicle) error {
 	for _, spot := range c.ParkingRepo.GetSpots() {
 		if !spot.status && spot.Level == 1 { // Assuming Level 1 is for cars
 			spot.status = true
+			spot.numberPlate = vehicle.NumberPlate
 			err := c.ParkingRepo.UpdateSpot(spot)
 			if err != nil {
 				return fmt.Errorf("failed to update parking spot: %v", err)
@@ -153,6 +186,7 @@ func (b *BikeParkingStrategy) ParkVehicle(vehicle Vehicle) error {
 	for _, spot := range b.ParkingRepo.GetSpots() {
 		if !spot.status && spot.Level == 2 { // Assuming Level 2 is for bikes
 			spot.status = true
+			spot.numberPlate = vehicle.NumberPlate
 			err := b.ParkingRepo.UpdateSpot(spot)
 			if err != nil {
 				return fmt.Errorf("failed to update parking spot: %v", err)
@@ -165,6 +199,7 @@ func (b *BikeParkingStrategy) ParkVehicle(vehicle Vehicle) error {
 	for _, spot := range b.ParkingRepo.GetSpots() {
 		if !spot.status && spot.Level == 1 { // Assuming Level 1 is for cars
 			spot.status = true
+			spot.numberPlate = vehicle.NumberPlate
 			err := b.ParkingRepo.UpdateSpot(spot)
 			if err != nil {
 				return fmt.Errorf("failed to update parking spot: %v", err)
@@ -176,11 +211,47 @@ func (b *BikeParkingStrategy) ParkVehicle(vehicle Vehicle) error {
 	return fmt.Errorf("no available parking spots for bikes or cars")
 }
 
+type ElectricParkingStrategy struct {
+	ParkingRepo IparkingRepo
+}
+
+func (e *ElectricParkingStrategy) ParkVehicle(vehicle Vehicle) error {
+	for _, spot := range e.ParkingRepo.GetSpots() {
+		if !spot.status && spot.Level == 1 && spot.HasCharger {
+			spot.status = true
+			spot.numberPlate = vehicle.NumberPlate
+			err := e.ParkingRepo.UpdateSpot(spot)
+			if err != nil {
+				return fmt.Errorf("failed to update parking spot: %v", err)
+			}
+			fmt.Printf("Vehicle %s parked in charging spot %d\n", vehicle.NumberPlate, spot.ID)
+			return nil
+		}
+	}
+	// If no charging spots are available, try regular car spots
+	for _, spot := range e.ParkingRepo.GetSpots() {
+		if !spot.status && spot.Level == 1 {
+			spot.status = true
+			spot.numberPlate = vehicle.NumberPlate
+			err := e.ParkingRepo.UpdateSpot(spot)
+			if err != nil {
+				return fmt.Errorf("failed to update parking spot: %v", err)
+			}
+			fmt.Printf("Vehicle %s parked in car spot %d\n", vehicle.NumberPlate, spot.ID)
+			return nil
+		}
+	}
+	return fmt.Errorf("no available parking spots for electric vehicles")
+}
+
 func main() {
 	// Example usage
 	parkingRepo := &ParkingRepo{
 		parkingSpots: make(map[int]*ParkingSpot),
 	}
+	for id := 1; id <= 8; id++ {
+		parkingRepo.parkingSpots[id] = &ParkingSpot{ID: id, Level: 1 + id%2, HasCharger: id == 8}
+	}
 	paymentService := &CardService{}
 
 	parkingService := &ParkingService{
@@ -198,4 +269,34 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+
+	// The same plate can't be parked twice
+	if err := parkingService.ParkVehicle(vehicle); err != nil {
+		fmt.Println(err)
+	}
+
+	// Leaving frees the spot, the plate can park again
+	if err := parkingService.UnParkVehicle(vehicle); err != nil {
+		panic(err)
+	}
+	if err := parkingService.UnParkVehicle(vehicle); err != nil {
+		fmt.Println(err)
+	}
+	if err := parkingService.ParkVehicle(vehicle); err != nil {
+		panic(err)
+	}
+
+	// Spots are handed out lowest ID first, so this always lands in spot 4
+	err = parkingService.ParkVehicle(Vehicle{NumberPlate: "XYZ789", Color: "Blue", Type: Car})
+	if err != nil {
+		panic(err)
+	}
+
+	// The first EV takes the charging spot, the next falls back to a regular one
+	for _, plate := range []string{"EV001", "EV002"} {
+		err = parkingService.ParkVehicle(Vehicle{NumberPlate: plate, Color: "White", Type: Electric})
+		if err != nil {
+			panic(err)
+		}
+	}
 }
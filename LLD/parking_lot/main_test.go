@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newTestService returns a service over spots 1 to n, odd IDs on level 2
+// for bikes, even IDs on level 1 for cars, the ones in chargers have a charger
+func newTestService(n int, chargers ...int) (*ParkingService, *ParkingRepo) {
+	repo := &ParkingRepo{parkingSpots: make(map[int]*ParkingSpot)}
+	for id := 1; id <= n; id++ {
+		repo.parkingSpots[id] = &ParkingSpot{ID: id, Level: 1 + id%2}
+	}
+	for _, id := range chargers {
+		repo.parkingSpots[id].HasCharger = true
+	}
+	return &ParkingService{parkingRepo: repo, paymentServ: &CardService{}}, repo
+}
+
+func TestParkRejectsPlateAlreadyParked(t *testing.T) {
+	service, _ := newTestService(4)
+	car := Vehicle{NumberPlate: "ABC123", Type: Car}
+	if err := service.ParkVehicle(car); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ParkVehicle(car); err == nil {
+		t.Fatal("parking ABC123 twice succeeded, want an error")
+	}
+	if spot := service.findParked("ABC123"); spot == nil || spot.ID != 2 {
+		t.Fatalf("ABC123 parked in %v, want spot 2", spot)
+	}
+}
+
+func TestUnParkFreesTheSpot(t *testing.T) {
+	service, repo := newTestService(4)
+	car := Vehicle{NumberPlate: "ABC123", Type: Car}
+	if err := service.ParkVehicle(car); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.UnParkVehicle(car); err != nil {
+		t.Fatal(err)
+	}
+	if spot := repo.parkingSpots[2]; spot.status || spot.numberPlate != "" {
+		t.Fatalf("spot 2 = %+v, want it free", spot)
+	}
+	if err := service.UnParkVehicle(car); err == nil {
+		t.Fatal("unparking a car that left succeeded, want an error")
+	}
+
+	// the plate can park again, in the freed spot
+	if err := service.ParkVehicle(car); err != nil {
+		t.Fatal(err)
+	}
+	if spot := service.findParked("ABC123"); spot == nil || spot.ID != 2 {
+		t.Fatalf("ABC123 parked in %v, want spot 2", spot)
+	}
+}
+
+func TestParkPicksLowestFreeSpot(t *testing.T) {
+	service, repo := newTestService(10)
+	for _, id := range []int{2, 6} {
+		repo.parkingSpots[id].status = true
+		repo.parkingSpots[id].numberPlate = fmt.Sprint("TAKEN", id)
+	}
+
+	for i := 0; i < 20; i++ {
+		if spots := repo.GetSpots(); spots[0].ID != 1 || spots[9].ID != 10 {
+			t.Fatalf("GetSpots returned %d first and %d last, want 1 and 10", spots[0].ID, spots[9].ID)
+		}
+	}
+	for _, tc := range []struct {
+		plate string
+		typ   VehicleType
+		spot  int
+	}{
+		{"CAR1", Car, 4},
+		{"CAR2", Car, 8},
+		{"BIKE1", Bike, 1},
+	} {
+		if err := service.ParkVehicle(Vehicle{NumberPlate: tc.plate, Type: tc.typ}); err != nil {
+			t.Fatal(err)
+		}
+		if spot := service.findParked(tc.plate); spot == nil || spot.ID != tc.spot {
+			t.Fatalf("%s parked in %v, want spot %d", tc.plate, spot, tc.spot)
+		}
+	}
+}
+
+func TestEVPrefersChargingSpot(t *testing.T) {
+	service, _ := newTestService(8, 6)
+	for _, tc := range []struct {
+		plate string
+		spot  int
+	}{
+		{"EV1", 6}, // the charger, though 2 is free
+		{"EV2", 2}, // no charger left, the lowest car spot
+	} {
+		if err := service.ParkVehicle(Vehicle{NumberPlate: tc.plate, Type: Electric}); err != nil {
+			t.Fatal(err)
+		}
+		if spot := service.findParked(tc.plate); spot == nil || spot.ID != tc.spot {
+			t.Fatalf("%s parked in %v, want spot %d", tc.plate, spot, tc.spot)
+		}
+	}
+
+	fee, err := getFeesStrategy(Vehicle{Type: Electric})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if carFee, _ := getFeesStrategy(Vehicle{Type: Car}); fee != carFee+evChargingSurcharge {
+		t.Fatalf("EV fee = %d, want the car fee %d plus %d", fee, carFee, evChargingSurcharge)
+	}
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -11,26 +16,184 @@ import (
 type Message struct {
 	Offset  int
 	Content string
+	Topic   string
 }
 
+// MessageHandler processes a delivered message, an error makes it retried.
+// Handlers acknowledge a message by calling Commit with its offset.
+type MessageHandler func(msg Message) error
+
 type Subscriber struct {
 	ID            int
-	CurrentOffset int
+	CurrentOffset int // next offset to deliver, moved by Commit
 	Done          chan struct{}
+	Handler       MessageHandler
+	deadLetters   []Message
+	inFlight      int           // offset delivered and waiting for a commit, -1 if none
+	committed     chan struct{} // wakes the consumer up on Commit
 	offsetLock    sync.Mutex
+
+	// fan-in, see SubscribeMulti
+	consumers  map[string]*Subscriber // topic -> consumer keeping that topic's offset
+	delivering string                 // topic of the message being handled
+	fanIn      *Subscriber            // set on a consumer, the subscriber it feeds
+}
+
+// TopicOffsets returns the next offset to deliver on every topic the
+// subscriber consumes through SubscribeMulti
+func (s *Subscriber) TopicOffsets() map[string]int {
+	s.offsetLock.Lock()
+	consumers := make(map[string]*Subscriber, len(s.consumers))
+	for topic, consumer := range s.consumers {
+		consumers[topic] = consumer
+	}
+	s.offsetLock.Unlock()
+
+	offsets := make(map[string]int, len(consumers))
+	for topic, consumer := range consumers {
+		consumer.offsetLock.Lock()
+		offsets[topic] = consumer.CurrentOffset
+		consumer.offsetLock.Unlock()
+	}
+	return offsets
 }
 
 func (s *Subscriber) SetOffset(offset int) {
 	s.offsetLock.Lock()
 	defer s.offsetLock.Unlock()
 	s.CurrentOffset = offset
+	s.inFlight = -1
 	fmt.Printf("Subscriber %d manually set offset to %d\n", s.ID, offset)
 }
 
+// Commit acknowledges the message at offset so the consumer moves past it.
+// Messages that are never committed are delivered again when the subscriber restarts.
+// For a fan-in subscriber it commits the topic of the message being handled.
+func (s *Subscriber) Commit(offset int) error {
+	s.offsetLock.Lock()
+	if s.delivering != "" {
+		consumer := s.consumers[s.delivering]
+		s.offsetLock.Unlock()
+		return consumer.Commit(offset)
+	}
+	defer s.offsetLock.Unlock()
+	if offset < s.CurrentOffset {
+		return fmt.Errorf("offset %d already committed", offset)
+	}
+	s.CurrentOffset = offset + 1
+	s.inFlight = -1
+	select {
+	case s.committed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
 type Topic struct {
 	Name        string
 	Messages    []Message
 	Subscribers []*Subscriber
+	// guards Messages, consumers read them without the topic service lock
+	messagesLock sync.RWMutex
+}
+
+// messageAt returns the message at offset, false if it isn't published yet
+func (t *Topic) messageAt(offset int) (Message, bool) {
+	t.messagesLock.RLock()
+	defer t.messagesLock.RUnlock()
+	if offset >= len(t.Messages) {
+		return Message{}, false
+	}
+	return t.Messages[offset], true
+}
+
+// messageCount returns how many messages the topic holds
+func (t *Topic) messageCount() int {
+	t.messagesLock.RLock()
+	defer t.messagesLock.RUnlock()
+	return len(t.Messages)
+}
+
+// --- Offset Storage ---
+
+// OffsetStore keeps the committed offset of every subscriber per topic,
+// so consumption resumes where it stopped after a restart
+type OffsetStore interface {
+	Save(topic string, subID int, offset int) error
+	Load(topic string, subID int) (int, bool)
+}
+
+func offsetKey(topic string, subID int) string {
+	return fmt.Sprintf("%s/%d", topic, subID)
+}
+
+type InMemoryOffsetStore struct {
+	offsets map[string]int
+	lock    sync.RWMutex
+}
+
+func NewInMemoryOffsetStore() *InMemoryOffsetStore {
+	return &InMemoryOffsetStore{offsets: make(map[string]int)}
+}
+
+func (m *InMemoryOffsetStore) Save(topic string, subID int, offset int) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.offsets[offsetKey(topic, subID)] = offset
+	return nil
+}
+
+func (m *InMemoryOffsetStore) Load(topic string, subID int) (int, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	offset, exists := m.offsets[offsetKey(topic, subID)]
+	return offset, exists
+}
+
+// JSONFileOffsetStore writes all the offsets to a JSON file on every save
+type JSONFileOffsetStore struct {
+	path    string
+	offsets map[string]int
+	lock    sync.RWMutex
+}
+
+// NewJSONFileOffsetStore loads the offsets saved in path, a missing file starts empty
+func NewJSONFileOffsetStore(path string) (*JSONFileOffsetStore, error) {
+	fs := &JSONFileOffsetStore{path: path, offsets: make(map[string]int)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fs.offsets); err != nil {
+		return nil, fmt.Errorf("reading offsets from %s: %w", path, err)
+	}
+	return fs, nil
+}
+
+func (f *JSONFileOffsetStore) Save(topic string, subID int, offset int) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.offsets[offsetKey(topic, subID)] = offset
+	data, err := json.Marshal(f.offsets)
+	if err != nil {
+		return err
+	}
+	// write and rename so a crash never leaves a half written file
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+func (f *JSONFileOffsetStore) Load(topic string, subID int) (int, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	offset, exists := f.offsets[offsetKey(topic, subID)]
+	return offset, exists
 }
 
 // --- Interfaces ---
@@ -39,12 +202,32 @@ type ITopicService interface {
 	CreateTopic(topic *Topic) error
 	AddSubscriber(topic string, subscriber *Subscriber) error
 	RemoveSubscriber(topicName string, subscriber *Subscriber) error
+	SubscribeMulti(topicNames []string, subscriber *Subscriber) error
+	UnsubscribeMulti(topicNames []string, subscriber *Subscriber) error
 	Publish(topic string, content string) error
+	Lag(topicName string) map[int]int
+	Health() Health
+	Peek(topicName string, fromOffset, count int) ([]Message, error)
 }
 
 type ISubscriberService interface {
 	CreateSubscriber(id int) *Subscriber
-	ConsumeMessages(s *Subscriber, topic *Topic)
+	ConsumeMessages(s *Subscriber, topic *Topic, handler MessageHandler)
+	DeadLetters(subscriberID int) []Message
+	FailedConsumers() []int
+}
+
+// Health is a liveness snapshot of the pub/sub service
+type Health struct {
+	Topics          int
+	Subscribers     int   // subscriptions across all the topics
+	Messages        int   // messages retained across all the topics
+	FailedConsumers []int // subscribers whose consumer panicked and stopped
+}
+
+// Healthy reports whether every consumer is still running
+func (h Health) Healthy() bool {
+	return len(h.FailedConsumers) == 0
 }
 
 // --- Services ---
@@ -83,7 +266,17 @@ func (ts *TopicService) AddSubscriber(topicName string, subscriber *Subscriber)
 	}
 	topic.Subscribers = append(topic.Subscribers, subscriber)
 
-	go ts.subscriberService.ConsumeMessages(subscriber, topic)
+	// a re-added subscriber restarts from its last committed offset
+	subscriber.offsetLock.Lock()
+	select {
+	case <-subscriber.Done:
+		subscriber.Done = make(chan struct{})
+	default:
+	}
+	subscriber.inFlight = -1
+	subscriber.offsetLock.Unlock()
+
+	go ts.subscriberService.ConsumeMessages(subscriber, topic, subscriber.Handler)
 
 	return nil
 }
@@ -97,7 +290,8 @@ func (ts *TopicService) RemoveSubscriber(topicName string, subscriber *Subscribe
 		return fmt.Errorf("topic not found")
 	}
 	for i, sub := range topic.Subscribers {
-		if sub.ID == subscriber.ID {
+		// fan-in consumers sharing the ID are left to UnsubscribeMulti
+		if sub.ID == subscriber.ID && sub.fanIn == nil {
 			topic.Subscribers = append(topic.Subscribers[:i], topic.Subscribers[i+1:]...)
 			select {
 			case <-sub.Done:
@@ -112,6 +306,95 @@ func (ts *TopicService) RemoveSubscriber(topicName string, subscriber *Subscribe
 	return fmt.Errorf("subscriber not found in topic")
 }
 
+// SubscribeMulti has one subscriber consume several topics, one message at a
+// time across all of them. Each topic is consumed by its own consumer keeping
+// that topic's offset, see TopicOffsets; a Commit by the subscriber's handler
+// commits the topic of the message it's handling. The subscriber's own
+// CurrentOffset isn't used.
+func (ts *TopicService) SubscribeMulti(topicNames []string, subscriber *Subscriber) error {
+	ts.topicLock.Lock()
+	defer ts.topicLock.Unlock()
+
+	subscriber.offsetLock.Lock()
+	defer subscriber.offsetLock.Unlock()
+	topics := make([]*Topic, 0, len(topicNames))
+	for _, name := range topicNames {
+		topic, exists := ts.topics[name]
+		if !exists {
+			return fmt.Errorf("topic %q not found", name)
+		}
+		if _, subscribed := subscriber.consumers[name]; subscribed {
+			return fmt.Errorf("subscriber %d already consumes topic %q", subscriber.ID, name)
+		}
+		topics = append(topics, topic)
+	}
+	if subscriber.consumers == nil {
+		subscriber.consumers = make(map[string]*Subscriber)
+	}
+
+	deliver := &sync.Mutex{}
+	for _, topic := range topics {
+		consumer := &Subscriber{
+			ID:        subscriber.ID,
+			Done:      make(chan struct{}),
+			inFlight:  -1,
+			committed: make(chan struct{}, 1),
+			fanIn:     subscriber,
+		}
+		consumer.Handler = func(msg Message) error {
+			deliver.Lock()
+			defer deliver.Unlock()
+
+			subscriber.offsetLock.Lock()
+			subscriber.delivering = msg.Topic
+			handler := subscriber.Handler
+			subscriber.offsetLock.Unlock()
+			defer func() {
+				subscriber.offsetLock.Lock()
+				subscriber.delivering = ""
+				subscriber.offsetLock.Unlock()
+			}()
+			return handler(msg)
+		}
+		subscriber.consumers[topic.Name] = consumer
+		topic.Subscribers = append(topic.Subscribers, consumer)
+		go ts.subscriberService.ConsumeMessages(consumer, topic, consumer.Handler)
+	}
+	return nil
+}
+
+// UnsubscribeMulti stops the subscriber's fan-in consumers on the topics, a
+// direct subscription through AddSubscriber is kept. The ones it's subscribed
+// to are all removed even when some topic is unknown.
+func (ts *TopicService) UnsubscribeMulti(topicNames []string, subscriber *Subscriber) error {
+	ts.topicLock.Lock()
+	defer ts.topicLock.Unlock()
+
+	subscriber.offsetLock.Lock()
+	defer subscriber.offsetLock.Unlock()
+	var missing []string
+	for _, name := range topicNames {
+		topic, exists := ts.topics[name]
+		consumer, subscribed := subscriber.consumers[name]
+		if !exists || !subscribed {
+			missing = append(missing, name)
+			continue
+		}
+		for i, sub := range topic.Subscribers {
+			if sub == consumer {
+				topic.Subscribers = append(topic.Subscribers[:i], topic.Subscribers[i+1:]...)
+				break
+			}
+		}
+		close(consumer.Done)
+		delete(subscriber.consumers, name)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("subscriber %d not found in topics %v", subscriber.ID, missing)
+	}
+	return nil
+}
+
 func (ts *TopicService) Publish(topicName string, content string) error {
 	ts.topicLock.Lock()
 	defer ts.topicLock.Unlock()
@@ -121,51 +404,236 @@ func (ts *TopicService) Publish(topicName string, content string) error {
 		return fmt.Errorf("topic not found")
 	}
 
+	topic.messagesLock.Lock()
+	defer topic.messagesLock.Unlock()
 	newMsg := Message{
 		Offset:  len(topic.Messages),
 		Content: content,
+		Topic:   topicName,
 	}
 	topic.Messages = append(topic.Messages, newMsg)
 
 	return nil
 }
 
-type SubscriberService struct{}
+// Lag returns, per subscriber ID, how many messages the subscriber
+// still has to consume on the topic. Unknown topics return nil.
+func (ts *TopicService) Lag(topicName string) map[int]int {
+	ts.topicLock.RLock()
+	defer ts.topicLock.RUnlock()
+
+	topic, exists := ts.topics[topicName]
+	if !exists {
+		return nil
+	}
+
+	latest := topic.messageCount()
+	lag := make(map[int]int, len(topic.Subscribers))
+	for _, sub := range topic.Subscribers {
+		sub.offsetLock.Lock()
+		lag[sub.ID] = latest - sub.CurrentOffset
+		sub.offsetLock.Unlock()
+	}
+	return lag
+}
+
+// Peek returns up to count messages starting at fromOffset without
+// moving any subscriber
+func (ts *TopicService) Peek(topicName string, fromOffset, count int) ([]Message, error) {
+	ts.topicLock.RLock()
+	defer ts.topicLock.RUnlock()
+
+	topic, exists := ts.topics[topicName]
+	if !exists {
+		return nil, fmt.Errorf("topic not found")
+	}
+	topic.messagesLock.RLock()
+	defer topic.messagesLock.RUnlock()
+	if fromOffset < 0 || fromOffset >= len(topic.Messages) {
+		return nil, fmt.Errorf("offset %d out of range [0, %d)", fromOffset, len(topic.Messages))
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	end := min(fromOffset+count, len(topic.Messages))
+	return append([]Message(nil), topic.Messages[fromOffset:end]...), nil
+}
+
+func (ts *TopicService) Health() Health {
+	ts.topicLock.RLock()
+	defer ts.topicLock.RUnlock()
+
+	health := Health{
+		Topics:          len(ts.topics),
+		FailedConsumers: ts.subscriberService.FailedConsumers(),
+	}
+	for _, topic := range ts.topics {
+		health.Subscribers += len(topic.Subscribers)
+		health.Messages += topic.messageCount()
+	}
+	return health
+}
+
+// maxDeliveryAttempts is how many times a failing message is handled
+// before it is moved to the subscriber's dead letters
+const maxDeliveryAttempts = 3
+
+type SubscriberService struct {
+	subscribers map[int]*Subscriber
+	offsets     OffsetStore
+	failed      map[int]bool // subscribers whose consumer panicked
+	lock        sync.RWMutex
+}
 
 func NewSubscriberService() ISubscriberService {
-	return &SubscriberService{}
+	return NewSubscriberServiceWithStore(NewInMemoryOffsetStore())
+}
+
+// NewSubscriberServiceWithStore creates a service resuming subscribers
+// from the offsets in store
+func NewSubscriberServiceWithStore(store OffsetStore) ISubscriberService {
+	return &SubscriberService{
+		subscribers: make(map[int]*Subscriber),
+		offsets:     store,
+		failed:      make(map[int]bool),
+	}
+}
+
+// saveOffset persists the subscriber offset, a failed save is only logged
+// since the offset is saved again on the next advance
+func (ss *SubscriberService) saveOffset(topic string, subID int, offset int) {
+	if err := ss.offsets.Save(topic, subID, offset); err != nil {
+		fmt.Printf("Subscriber %d failed to save offset %d: %v\n", subID, offset, err)
+	}
 }
 
 func (ss *SubscriberService) CreateSubscriber(id int) *Subscriber {
-	return &Subscriber{
+	s := &Subscriber{
 		ID:            id,
 		CurrentOffset: 0,
 		Done:          make(chan struct{}),
+		inFlight:      -1,
+		committed:     make(chan struct{}, 1),
+	}
+	s.Handler = func(msg Message) error {
+		fmt.Printf("Subscriber %d received [offset %d]: %s\n", s.ID, msg.Offset, msg.Content)
+		return s.Commit(msg.Offset)
 	}
+
+	ss.lock.Lock()
+	ss.subscribers[id] = s
+	ss.lock.Unlock()
+	return s
 }
 
-func (ss *SubscriberService) ConsumeMessages(s *Subscriber, topic *Topic) {
+// ConsumeMessages delivers the message at the subscriber's offset and waits
+// for it to be committed before delivering the next one (at-least-once).
+// A message failing every attempt is dead-lettered and skipped.
+// It starts from the offset in the offset store and saves it as it advances.
+func (ss *SubscriberService) ConsumeMessages(s *Subscriber, topic *Topic, handler MessageHandler) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Subscriber %d consumer crashed: %v\n", s.ID, r)
+			ss.lock.Lock()
+			ss.failed[s.ID] = true
+			ss.lock.Unlock()
+		}
+	}()
+	// a restarted consumer is running again
+	ss.lock.Lock()
+	delete(ss.failed, s.ID)
+	ss.lock.Unlock()
+
+	s.offsetLock.Lock()
+	done := s.Done
+	if offset, exists := ss.offsets.Load(topic.Name, s.ID); exists {
+		s.CurrentOffset = offset
+		s.inFlight = -1
+	}
+	saved := s.CurrentOffset
+	s.offsetLock.Unlock()
 	for {
 		select {
-		case <-s.Done:
+		case <-done:
+			s.offsetLock.Lock()
+			if s.CurrentOffset != saved {
+				ss.saveOffset(topic.Name, s.ID, s.CurrentOffset)
+			}
+			s.offsetLock.Unlock()
 			fmt.Printf("Subscriber %d stopping consumption.\n", s.ID)
 			return
 		default:
 			s.offsetLock.Lock()
-			if s.CurrentOffset < len(topic.Messages) {
-				msg := topic.Messages[s.CurrentOffset]
-				//	s.Channel <- msg
-				fmt.Printf("Subscriber %d received [offset %d]: %s\n", s.ID, msg.Offset, msg.Content)
-				s.CurrentOffset++
+			if s.CurrentOffset != saved {
+				saved = s.CurrentOffset
+				ss.saveOffset(topic.Name, s.ID, saved)
+			}
+			msg, published := topic.messageAt(s.CurrentOffset)
+			if published && s.inFlight != s.CurrentOffset {
+				offset := s.CurrentOffset
+				s.inFlight = offset
 				s.offsetLock.Unlock()
+
+				var err error
+				for attempt := 1; attempt <= maxDeliveryAttempts; attempt++ {
+					if err = handler(msg); err == nil {
+						break
+					}
+				}
+
+				if err != nil {
+					s.offsetLock.Lock()
+					fmt.Printf("Subscriber %d dead-lettered [offset %d]: %v\n", s.ID, msg.Offset, err)
+					s.deadLetters = append(s.deadLetters, msg)
+					// a manual SetOffset while handling wins over advancing
+					if s.CurrentOffset == offset {
+						s.CurrentOffset++
+						s.inFlight = -1
+					}
+					s.offsetLock.Unlock()
+				}
 			} else {
 				s.offsetLock.Unlock()
-				time.Sleep(500 * time.Millisecond) // Wait for new messages
+				// Wait for new messages or a commit
+				select {
+				case <-s.committed:
+				case <-done:
+				case <-time.After(500 * time.Millisecond):
+				}
 			}
 		}
 	}
 }
 
+// FailedConsumers returns the sorted IDs of the subscribers whose consumer
+// stopped on a panic
+func (ss *SubscriberService) FailedConsumers() []int {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+
+	var ids []int
+	for id := range ss.failed {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// DeadLetters returns the messages the subscriber failed to handle
+// after all the delivery attempts
+func (ss *SubscriberService) DeadLetters(subscriberID int) []Message {
+	ss.lock.RLock()
+	s, exists := ss.subscribers[subscriberID]
+	ss.lock.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	s.offsetLock.Lock()
+	defer s.offsetLock.Unlock()
+	return append([]Message(nil), s.deadLetters...)
+}
+
 // --- Main Demo ---
 
 func main() {
@@ -180,18 +648,45 @@ func main() {
 	// Create Subscriber
 	sub := subscriberService.CreateSubscriber(1)
 	subb := subscriberService.CreateSubscriber(2)
+	subb.Handler = func(msg Message) error {
+		if msg.Offset == 1 {
+			return fmt.Errorf("cannot process %q", msg.Content)
+		}
+		fmt.Printf("Subscriber %d received [offset %d]: %s\n", subb.ID, msg.Offset, msg.Content)
+		return subb.Commit(msg.Offset)
+	}
+	// subscriber 3 crashes before committing its first message
+	subc := subscriberService.CreateSubscriber(3)
+	subc.Handler = func(msg Message) error {
+		fmt.Printf("Subscriber %d received [offset %d] but did not commit\n", subc.ID, msg.Offset)
+		return nil
+	}
 
 	// Add Subscriber to Topic
 	_ = topicService.AddSubscriber("technology", sub)
 	_ = topicService.AddSubscriber("technology", subb)
+	_ = topicService.AddSubscriber("technology", subc)
 
 	// Publish Messages
 	_ = topicService.Publish("technology", "Message 1: New AI model released!")
 	time.Sleep(500 * time.Millisecond)
 	_ = topicService.Publish("technology", "Message 2: Quantum computing breakthrough!")
 	time.Sleep(1000 * time.Millisecond)
+	fmt.Println("Dead letters of subscriber 2:", subscriberService.DeadLetters(subb.ID))
 	_ = topicService.RemoveSubscriber("technology", subb)
+
+	// restarting subscriber 3 redelivers the uncommitted message
+	_ = topicService.RemoveSubscriber("technology", subc)
+	subc.Handler = func(msg Message) error {
+		fmt.Printf("Subscriber %d received [offset %d]: %s\n", subc.ID, msg.Offset, msg.Content)
+		return subc.Commit(msg.Offset)
+	}
+	_ = topicService.AddSubscriber("technology", subc)
 	_ = topicService.Publish("technology", "Message 3: Self-driving cars 2.0 announced!")
+	fmt.Println("Lag:", topicService.Lag("technology"))
+	if msgs, err := topicService.Peek("technology", 1, 2); err == nil {
+		fmt.Println("Peek from offset 1:", msgs)
+	}
 	time.Sleep(2 * time.Second)
 
 	//---- Change Offset Manually ----
@@ -199,4 +694,66 @@ func main() {
 	sub.SetOffset(1)
 
 	time.Sleep(5 * time.Second) // Let consumer reconsume from offset 1
+
+	// a handler bug takes subscriber 4 down, health reports it
+	subd := subscriberService.CreateSubscriber(4)
+	subd.Handler = func(msg Message) error {
+		var missing map[string]int
+		missing[msg.Content]++
+		return nil
+	}
+	_ = topicService.AddSubscriber("technology", subd)
+	time.Sleep(100 * time.Millisecond)
+	health := topicService.Health()
+	fmt.Printf("Health: %+v, healthy: %t\n", health, health.Healthy())
+
+	// ---- One subscriber on several topics ----
+	fmt.Println("=== Fan-in from sports and weather ===")
+	_ = topicService.CreateTopic(&Topic{Name: "sports"})
+	_ = topicService.CreateTopic(&Topic{Name: "weather"})
+	fan := subscriberService.CreateSubscriber(5)
+	fan.Handler = func(msg Message) error {
+		fmt.Printf("Subscriber %d received [%s offset %d]: %s\n", fan.ID, msg.Topic, msg.Offset, msg.Content)
+		return fan.Commit(msg.Offset)
+	}
+	_ = topicService.SubscribeMulti([]string{"sports", "weather"}, fan)
+	_ = topicService.Publish("sports", "Final score 2-1")
+	_ = topicService.Publish("weather", "Sunny all week")
+	_ = topicService.Publish("sports", "Transfer window opens")
+	time.Sleep(500 * time.Millisecond)
+	fmt.Println("Offsets:", fan.TopicOffsets(), "lag sports:", topicService.Lag("sports"), "weather:", topicService.Lag("weather"))
+	if err := topicService.UnsubscribeMulti([]string{"sports", "weather"}, fan); err != nil {
+		fmt.Println(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// ---- Resume after a restart ----
+	fmt.Println("=== Restarting with a file offset store ===")
+	offsetsPath := filepath.Join(os.TempDir(), "pubsub_offsets.json")
+	_ = os.Remove(offsetsPath)
+	resume(offsetsPath, []string{"Before restart"})
+	resume(offsetsPath, []string{"Before restart", "After restart"})
+}
+
+// resume starts fresh services on the offset file and replays the topic log,
+// the subscriber only receives what it hasn't committed before
+func resume(offsetsPath string, log []string) {
+	store, err := NewJSONFileOffsetStore(offsetsPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	subscriberService := NewSubscriberServiceWithStore(store)
+	topicService := NewTopicService(subscriberService)
+	news := &Topic{Name: "news"}
+	_ = topicService.CreateTopic(news)
+	for _, content := range log {
+		_ = topicService.Publish("news", content)
+	}
+
+	reader := subscriberService.CreateSubscriber(7)
+	_ = topicService.AddSubscriber("news", reader)
+	time.Sleep(500 * time.Millisecond)
+	_ = topicService.RemoveSubscriber("news", reader)
+	time.Sleep(100 * time.Millisecond)
 }
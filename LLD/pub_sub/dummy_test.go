@@ -0,0 +1,321 @@
+package main
+
+// dummy.go is a program of its own, run with: go test dummy.go dummy_test.go
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventually polls cond until it holds, consumers only look for new
+// messages every half a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// recorder is a subscriber handler keeping what it received
+type recorder struct {
+	mu       sync.Mutex
+	received []Message
+}
+
+func (r *recorder) handler(s *Subscriber) MessageHandler {
+	return func(msg Message) error {
+		r.mu.Lock()
+		r.received = append(r.received, msg)
+		r.mu.Unlock()
+		return s.Commit(msg.Offset)
+	}
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.received)
+}
+
+func (r *recorder) contents() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var contents []string
+	for _, msg := range r.received {
+		contents = append(contents, msg.Topic+":"+msg.Content)
+	}
+	return contents
+}
+
+func TestSubscribeMultiReceivesFromEveryTopic(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "sports"})
+	topicService.CreateTopic(&Topic{Name: "weather"})
+
+	fan := subscriberService.CreateSubscriber(1)
+	rec := &recorder{}
+	fan.Handler = rec.handler(fan)
+	if err := topicService.SubscribeMulti([]string{"sports", "weather"}, fan); err != nil {
+		t.Fatal(err)
+	}
+	topicService.Publish("sports", "goal")
+	topicService.Publish("weather", "sunny")
+	topicService.Publish("sports", "final")
+
+	eventually(t, "three messages", func() bool { return rec.count() == 3 })
+	got := rec.contents()
+	sort.Strings(got)
+	if want := []string{"sports:final", "sports:goal", "weather:sunny"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if offsets := fan.TopicOffsets(); !reflect.DeepEqual(offsets, map[string]int{"sports": 2, "weather": 1}) {
+		t.Fatalf("offsets = %v, want sports 2 and weather 1", offsets)
+	}
+	if fan.CurrentOffset != 0 {
+		t.Fatalf("subscriber offset = %d, want it untouched", fan.CurrentOffset)
+	}
+	if err := topicService.SubscribeMulti([]string{"sports"}, fan); err == nil {
+		t.Fatal("subscribing twice to sports succeeded, want an error")
+	}
+}
+
+func TestUnsubscribeMultiKeepsDirectSubscription(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "sports"})
+	topicService.CreateTopic(&Topic{Name: "weather"})
+
+	// one subscriber on sports directly, and fanning in from both topics
+	direct := subscriberService.CreateSubscriber(1)
+	directRec := &recorder{}
+	direct.Handler = directRec.handler(direct)
+	topicService.AddSubscriber("sports", direct)
+
+	fan := &Subscriber{ID: 1, Done: make(chan struct{}), inFlight: -1, committed: make(chan struct{}, 1)}
+	fanRec := &recorder{}
+	fan.Handler = fanRec.handler(fan)
+	if err := topicService.SubscribeMulti([]string{"sports", "weather"}, fan); err != nil {
+		t.Fatal(err)
+	}
+	if err := topicService.UnsubscribeMulti([]string{"sports", "weather"}, fan); err != nil {
+		t.Fatal(err)
+	}
+	if err := topicService.UnsubscribeMulti([]string{"sports"}, fan); err == nil {
+		t.Fatal("unsubscribing twice succeeded, want an error")
+	}
+
+	topicService.Publish("sports", "goal")
+	eventually(t, "the direct subscriber", func() bool { return directRec.count() == 1 })
+	if lag := topicService.Lag("sports"); !reflect.DeepEqual(lag, map[int]int{1: 0}) {
+		t.Fatalf("lag = %v, want only the direct subscription", lag)
+	}
+	if fanRec.count() != 0 {
+		t.Fatalf("unsubscribed fan-in received %v", fanRec.contents())
+	}
+}
+
+func TestLagCountsUncommittedMessages(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "news"})
+	for _, content := range []string{"a", "b", "c", "d", "e"} {
+		topicService.Publish("news", content)
+	}
+
+	// the reader stops committing after the first two messages
+	reader := subscriberService.CreateSubscriber(1)
+	reader.Handler = func(msg Message) error {
+		if msg.Offset < 2 {
+			return reader.Commit(msg.Offset)
+		}
+		return nil
+	}
+	idle := subscriberService.CreateSubscriber(2)
+	idle.Handler = func(Message) error { return nil }
+	topicService.AddSubscriber("news", reader)
+	topicService.AddSubscriber("news", idle)
+
+	want := map[int]int{1: 3, 2: 5}
+	eventually(t, "the reader to commit twice", func() bool {
+		return reflect.DeepEqual(topicService.Lag("news"), want)
+	})
+	if lag := topicService.Lag("missing"); lag != nil {
+		t.Fatalf("lag of an unknown topic = %v, want nil", lag)
+	}
+}
+
+func TestFailingMessageIsDeadLettered(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "orders"})
+	topicService.Publish("orders", "poison")
+
+	sub := subscriberService.CreateSubscriber(1)
+	var mu sync.Mutex
+	attempts := 0
+	sub.Handler = func(Message) error {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		return errors.New("cannot process")
+	}
+	topicService.AddSubscriber("orders", sub)
+
+	eventually(t, "the dead letter", func() bool { return len(subscriberService.DeadLetters(1)) == 1 })
+	if got := subscriberService.DeadLetters(1)[0]; got.Content != "poison" || got.Offset != 0 {
+		t.Fatalf("dead letter = %+v, want the poison message", got)
+	}
+	mu.Lock()
+	if attempts != maxDeliveryAttempts {
+		t.Errorf("handled %d times, want %d", attempts, maxDeliveryAttempts)
+	}
+	mu.Unlock()
+	if lag := topicService.Lag("orders"); lag[1] != 0 {
+		t.Fatalf("lag = %v, want the offset moved past the dead letter", lag)
+	}
+	if letters := subscriberService.DeadLetters(42); letters != nil {
+		t.Fatalf("dead letters of an unknown subscriber = %v, want nil", letters)
+	}
+}
+
+func TestUncommittedMessageRedeliveredOnRestart(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "news"})
+	topicService.Publish("news", "first")
+	topicService.Publish("news", "second")
+
+	// the subscriber crashes before committing the first message
+	sub := subscriberService.CreateSubscriber(1)
+	crashed := &recorder{}
+	sub.Handler = func(msg Message) error {
+		crashed.mu.Lock()
+		crashed.received = append(crashed.received, msg)
+		crashed.mu.Unlock()
+		return nil
+	}
+	topicService.AddSubscriber("news", sub)
+	eventually(t, "the first delivery", func() bool { return crashed.count() == 1 })
+	time.Sleep(50 * time.Millisecond)
+	if crashed.count() != 1 {
+		t.Fatalf("received %v before committing, want only the first message", crashed.contents())
+	}
+	topicService.RemoveSubscriber("news", sub)
+
+	restarted := &recorder{}
+	sub.Handler = restarted.handler(sub)
+	topicService.AddSubscriber("news", sub)
+	eventually(t, "both messages", func() bool { return restarted.count() == 2 })
+	if got := restarted.contents(); !reflect.DeepEqual(got, []string{"news:first", "news:second"}) {
+		t.Fatalf("after restart got %v, want the uncommitted message first", got)
+	}
+	if err := sub.Commit(0); err == nil {
+		t.Fatal("committing an old offset succeeded, want an error")
+	}
+}
+
+func TestConsumptionResumesFromFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets.json")
+	run := func(log []string) []string {
+		t.Helper()
+		store, err := NewJSONFileOffsetStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subscriberService := NewSubscriberServiceWithStore(store)
+		topicService := NewTopicService(subscriberService)
+		topicService.CreateTopic(&Topic{Name: "news"})
+		for _, content := range log {
+			topicService.Publish("news", content)
+		}
+
+		reader := subscriberService.CreateSubscriber(7)
+		rec := &recorder{}
+		reader.Handler = rec.handler(reader)
+		topicService.AddSubscriber("news", reader)
+		eventually(t, "the saved offset", func() bool {
+			offset, _ := store.Load("news", 7)
+			return offset == len(log)
+		})
+		topicService.RemoveSubscriber("news", reader)
+		return rec.contents()
+	}
+
+	if got := run([]string{"a", "b"}); !reflect.DeepEqual(got, []string{"news:a", "news:b"}) {
+		t.Fatalf("first run got %v", got)
+	}
+	if got := run([]string{"a", "b", "c"}); !reflect.DeepEqual(got, []string{"news:c"}) {
+		t.Fatalf("after restart got %v, want only the new message", got)
+	}
+}
+
+func TestHealthCountsAndFailedConsumers(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "news"})
+	topicService.CreateTopic(&Topic{Name: "sports"})
+	topicService.Publish("news", "a")
+	topicService.Publish("news", "b")
+	topicService.Publish("sports", "c")
+
+	topicService.AddSubscriber("news", subscriberService.CreateSubscriber(1))
+	topicService.AddSubscriber("sports", subscriberService.CreateSubscriber(2))
+	buggy := subscriberService.CreateSubscriber(3)
+	buggy.Handler = func(Message) error { panic("handler bug") }
+	topicService.AddSubscriber("news", buggy)
+
+	eventually(t, "the crash", func() bool { return !topicService.Health().Healthy() })
+	health := topicService.Health()
+	want := Health{Topics: 2, Subscribers: 3, Messages: 3, FailedConsumers: []int{3}}
+	if !reflect.DeepEqual(health, want) {
+		t.Fatalf("health = %+v, want %+v", health, want)
+	}
+}
+
+func TestPeekDoesNotMoveSubscribers(t *testing.T) {
+	subscriberService := NewSubscriberService()
+	topicService := NewTopicService(subscriberService)
+	topicService.CreateTopic(&Topic{Name: "news"})
+	for _, content := range []string{"a", "b", "c", "d", "e"} {
+		topicService.Publish("news", content)
+	}
+	idle := subscriberService.CreateSubscriber(1)
+	idle.Handler = func(Message) error { return nil }
+	topicService.AddSubscriber("news", idle)
+
+	msgs, err := topicService.Peek("news", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, msg := range msgs {
+		got = append(got, msg.Content)
+	}
+	if !reflect.DeepEqual(got, []string{"b", "c", "d"}) || msgs[0].Offset != 1 {
+		t.Fatalf("peeked %v, want b c d from offset 1", msgs)
+	}
+	if msgs, err := topicService.Peek("news", 3, 10); err != nil || len(msgs) != 2 {
+		t.Fatalf("peek past the end = %v, %v, want the last two messages", msgs, err)
+	}
+	for _, offset := range []int{-1, 5} {
+		if _, err := topicService.Peek("news", offset, 1); err == nil {
+			t.Errorf("peek from offset %d succeeded, want an error", offset)
+		}
+	}
+	if _, err := topicService.Peek("missing", 0, 1); err == nil {
+		t.Error("peek on an unknown topic succeeded, want an error")
+	}
+	if lag := topicService.Lag("news"); lag[1] != 5 {
+		t.Fatalf("lag = %v, want the subscriber still at offset 0", lag)
+	}
+}
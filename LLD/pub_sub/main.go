@@ -26,10 +26,20 @@ func main() {
 	pubSubService := NewPubSubService(topicService, subscriberService)
 	pubSubService.TopicSerivce.CreateTopic(model.NewTopic("topic1"))
 	sub1 := pubSubService.SubscriberService.CreateSubscriber(1)
-	pubSubService.TopicSerivce.AddSubscriber("topic1", sub1)
+	pubSubService.TopicSerivce.AddSubscriber("topic1", sub1, model.Block)
+	// a slow subscriber that only keeps the latest two messages
+	sub2 := pubSubService.SubscriberService.CreateBufferedSubscriber(2, 2)
+	pubSubService.TopicSerivce.AddSubscriber("topic1", sub2, model.DropOldest)
 	pubSubService.TopicSerivce.Publish("topic1", model.Message{Content: "Hello World"})
 	pubSubService.TopicSerivce.Publish("topic1", model.Message{Content: "Hello World2"})
 	pubSubService.SubscriberService.ConsumerMessage(sub1)
 	pubSubService.TopicSerivce.Publish("topic1", model.Message{Content: "Hello World3"})
+
+	// sub3 follows every tech topic through a wildcard subscription
+	pubSubService.TopicSerivce.CreateTopic(model.NewTopic("tech.ai"))
+	sub3 := pubSubService.SubscriberService.CreateSubscriber(3)
+	pubSubService.TopicSerivce.AddSubscriber("tech.*", sub3, model.Block)
+	pubSubService.SubscriberService.ConsumerMessage(sub3)
+	pubSubService.TopicSerivce.Publish("tech.ai", model.Message{Content: "New model released"})
 	time.Sleep(10 * time.Second)
 }
@@ -28,8 +28,18 @@ type Subscriber struct {
 	consume       func(msg *Message)
 	TotalRetry    int
 	RetryStrategy RetryStrategy
+	Overflow      OverflowPolicy
 }
 
+// OverflowPolicy decides what publish does when a subscriber's buffer is full
+type OverflowPolicy int
+
+const (
+	Block      OverflowPolicy = iota // publish waits until the subscriber frees space
+	DropOldest                       // the oldest buffered message is discarded
+	DropNewest                       // the message being published is discarded
+)
+
 type RetryStrategy int
 
 const (
@@ -6,8 +6,12 @@ import (
 	"github.com/SahilSrivastava/Downloads/machinecoding/cache_system/retry"
 )
 
+// DefaultBufferSize is how many undelivered messages a subscriber can hold
+const DefaultBufferSize = 100
+
 type ISubscriberService interface {
 	CreateSubscriber(id int) *model.Subscriber
+	CreateBufferedSubscriber(id, bufferSize int) *model.Subscriber
 	ConsumerMessage(s *model.Subscriber)
 }
 
@@ -22,9 +26,13 @@ func NewSubscriberService() *SubscriberService {
 }
 
 func (s SubscriberService) CreateSubscriber(id int) *model.Subscriber {
+	return s.CreateBufferedSubscriber(id, DefaultBufferSize)
+}
+
+func (s SubscriberService) CreateBufferedSubscriber(id, bufferSize int) *model.Subscriber {
 	return &model.Subscriber{
 		ID:         id,
-		Ch:         make(chan model.Message, 100), // Buffered channel for messages
+		Ch:         make(chan model.Message, bufferSize), // Bounded buffer for messages
 		Done:       make(chan bool),
 		TotalRetry: 3,
 	}
@@ -4,44 +4,144 @@ import (
 	"fmt"
 	"github.com/SahilSrivastava/Downloads/machinecoding/cache_system/model"
 	"github.com/SahilSrivastava/Downloads/machinecoding/cache_system/repository"
+	"strings"
 	"sync"
 )
 
 type ITopicService interface {
 	CreateTopic(topic *model.Topic) error
-	AddSubscriber(topic string, subscriber *model.Subscriber) error
+	AddSubscriber(topic string, subscriber *model.Subscriber, policy model.OverflowPolicy) error
 	Publish(topic string, msg model.Message) error
 }
 
 type TopicService struct {
 	repo repository.ITopicRepository
-	mu   sync.Mutex
+	// pattern -> subscriber ID -> subscriber, for subscriptions like "tech.*"
+	patternSubscribers map[string]map[int]*model.Subscriber
+	mu                 sync.Mutex
 }
 
 func NewTopicService(repo repository.ITopicRepository) *TopicService {
 	return &TopicService{
-		repo: repo,
+		repo:               repo,
+		patternSubscribers: make(map[string]map[int]*model.Subscriber),
 	}
 }
 
+// isPattern reports whether the topic name contains a wildcard segment
+func isPattern(topic string) bool {
+	return strings.Contains(topic, "*")
+}
+
+// matchTopic matches dot separated topic names, a "*" segment in the pattern
+// matches exactly one segment, eg. "tech.*" matches "tech.ai" but not "tech"
+func matchTopic(pattern, topic string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	topicSegments := strings.Split(topic, ".")
+	if len(patternSegments) != len(topicSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != topicSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (ts *TopicService) CreateTopic(topic *model.Topic) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
 	return ts.repo.CreateTopic(topic)
 }
 
-func (ts *TopicService) AddSubscriber(topic string, subscriber *model.Subscriber) error {
-	return ts.repo.AddSubscriber(topic, subscriber)
+// AddSubscriber subscribes to a topic, or to every topic matching a pattern
+// like "tech.*", including topics created later
+func (ts *TopicService) AddSubscriber(topic string, subscriber *model.Subscriber, policy model.OverflowPolicy) error {
+	subscriber.Overflow = policy
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if !isPattern(topic) {
+		return ts.repo.AddSubscriber(topic, subscriber)
+	}
+	if _, exists := ts.patternSubscribers[topic]; !exists {
+		ts.patternSubscribers[topic] = make(map[int]*model.Subscriber)
+	}
+	ts.patternSubscribers[topic][subscriber.ID] = subscriber
+	return nil
 }
 
+// Publish delivers the message to the topic's subscribers and the matching
+// pattern subscribers. The delivery runs without the service lock, so a
+// subscriber blocking publish doesn't hold up the other topics.
 func (t *TopicService) Publish(topic string, msg model.Message) error {
+	topicDetail, recipients, err := t.recipients(topic)
+	if err != nil {
+		return err
+	}
+	for _, subscriber := range recipients {
+		deliver(subscriber, msg)
+	}
+	fmt.Printf("Message published to topic %s: %s\n", topicDetail.Name, msg.Content)
+	return nil
+}
+
+// recipients looks up the topic and copies out the subscribers to deliver to
+func (t *TopicService) recipients(topic string) (*model.Topic, []*model.Subscriber, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	topicDetail, err := t.repo.GetTopic(topic)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	if topicDetail == nil {
+		return nil, nil, fmt.Errorf("topic %s not found", topic)
 	}
+	var recipients []*model.Subscriber
+	delivered := make(map[int]bool)
 	for _, subscriber := range topicDetail.Subscribers {
+		recipients = append(recipients, subscriber)
+		delivered[subscriber.ID] = true
+	}
+	for pattern, subscribers := range t.patternSubscribers {
+		if !matchTopic(pattern, topic) {
+			continue
+		}
+		// a subscriber matching through several subscriptions gets the message once
+		for id, subscriber := range subscribers {
+			if !delivered[id] {
+				recipients = append(recipients, subscriber)
+				delivered[id] = true
+			}
+		}
+	}
+	return topicDetail, recipients, nil
+}
+
+// deliver pushes the message to the subscriber's bounded buffer,
+// applying the subscriber's overflow policy when the buffer is full
+func deliver(subscriber *model.Subscriber, msg model.Message) {
+	switch subscriber.Overflow {
+	case model.DropNewest:
+		select {
+		case subscriber.Ch <- msg:
+		default:
+			fmt.Printf("Subscriber %d buffer full, dropped: %s\n", subscriber.ID, msg.Content)
+		}
+	case model.DropOldest:
+		for {
+			select {
+			case subscriber.Ch <- msg:
+				return
+			default:
+			}
+			select {
+			case old := <-subscriber.Ch:
+				fmt.Printf("Subscriber %d buffer full, dropped: %s\n", subscriber.ID, old.Content)
+			default:
+			}
+		}
+	default:
 		subscriber.Ch <- msg
 	}
-	fmt.Printf("Message published to topic %s: %s\n", topicDetail.Name, msg.Content)
-	return nil
 }
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SahilSrivastava/Downloads/machinecoding/cache_system/model"
+	"github.com/SahilSrivastava/Downloads/machinecoding/cache_system/repository"
+)
+
+// newTestService returns a topic service with the given topics created
+func newTestService(topics ...string) *TopicService {
+	ts := NewTopicService(repository.NewTopicRepository())
+	for _, name := range topics {
+		ts.CreateTopic(model.NewTopic(name))
+	}
+	return ts
+}
+
+// drain returns the contents of the messages buffered for the subscriber
+func drain(subscriber *model.Subscriber) []string {
+	var contents []string
+	for {
+		select {
+		case msg := <-subscriber.Ch:
+			contents = append(contents, msg.Content)
+		default:
+			return contents
+		}
+	}
+}
+
+func publishAll(t *testing.T, ts *TopicService, topic string, contents ...string) {
+	t.Helper()
+	for _, content := range contents {
+		if err := ts.Publish(topic, model.Message{Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	ts := newTestService("news")
+	subscriber := NewSubscriberService().CreateBufferedSubscriber(1, 2)
+	ts.AddSubscriber("news", subscriber, model.DropNewest)
+
+	publishAll(t, ts, "news", "m1", "m2", "m3")
+
+	if got := drain(subscriber); len(got) != 2 || got[0] != "m1" || got[1] != "m2" {
+		t.Fatalf("got %v, want [m1 m2]", got)
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	ts := newTestService("news")
+	subscriber := NewSubscriberService().CreateBufferedSubscriber(1, 2)
+	ts.AddSubscriber("news", subscriber, model.DropOldest)
+
+	publishAll(t, ts, "news", "m1", "m2", "m3")
+
+	if got := drain(subscriber); len(got) != 2 || got[0] != "m2" || got[1] != "m3" {
+		t.Fatalf("got %v, want [m2 m3]", got)
+	}
+}
+
+func TestOverflowBlockWaitsForSpace(t *testing.T) {
+	ts := newTestService("news")
+	subscriber := NewSubscriberService().CreateBufferedSubscriber(1, 1)
+	ts.AddSubscriber("news", subscriber, model.Block)
+	publishAll(t, ts, "news", "m1")
+
+	published := make(chan error)
+	go func() {
+		published <- ts.Publish("news", model.Message{Content: "m2"})
+	}()
+	select {
+	case <-published:
+		t.Fatal("publish to a full buffer returned, want it to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if msg := <-subscriber.Ch; msg.Content != "m1" {
+		t.Fatalf("got %s, want m1", msg.Content)
+	}
+	if err := <-published; err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(subscriber); len(got) != 1 || got[0] != "m2" {
+		t.Fatalf("got %v, want [m2]", got)
+	}
+}
+
+func TestBlockedPublishDoesNotStallOtherTopics(t *testing.T) {
+	ts := newTestService("slow", "fast")
+	slow := NewSubscriberService().CreateBufferedSubscriber(1, 1)
+	ts.AddSubscriber("slow", slow, model.Block)
+	publishAll(t, ts, "slow", "m1")
+	go ts.Publish("slow", model.Message{Content: "m2"}) // blocks until slow reads
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	fast := NewSubscriberService().CreateBufferedSubscriber(2, 1)
+	go func() {
+		defer close(done)
+		ts.AddSubscriber("fast", fast, model.Block)
+		ts.Publish("fast", model.Message{Content: "f1"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish to another topic is stalled by a blocked subscriber")
+	}
+	if got := drain(fast); len(got) != 1 || got[0] != "f1" {
+		t.Fatalf("got %v, want [f1]", got)
+	}
+	<-slow.Ch
+}
+
+func TestWildcardSubscriberReceivesMatchingTopics(t *testing.T) {
+	ts := newTestService("tech.ai", "tech", "tech.ai.chips", "sports.ai")
+	subscriber := NewSubscriberService().CreateBufferedSubscriber(1, 10)
+	if err := ts.AddSubscriber("tech.*", subscriber, model.DropNewest); err != nil {
+		t.Fatal(err)
+	}
+	direct := NewSubscriberService().CreateBufferedSubscriber(2, 10)
+	ts.AddSubscriber("tech.ai", direct, model.DropNewest)
+
+	publishAll(t, ts, "tech.ai", "model released")
+	publishAll(t, ts, "tech", "not a match")
+	publishAll(t, ts, "tech.ai.chips", "too deep")
+	publishAll(t, ts, "sports.ai", "other prefix")
+
+	if got := drain(subscriber); len(got) != 1 || got[0] != "model released" {
+		t.Fatalf("pattern subscriber got %v, want [model released]", got)
+	}
+	if got := drain(direct); len(got) != 1 || got[0] != "model released" {
+		t.Fatalf("exact subscriber got %v, want [model released]", got)
+	}
+
+	// a topic created after subscribing matches too
+	ts.CreateTopic(model.NewTopic("tech.web"))
+	publishAll(t, ts, "tech.web", "new framework")
+	if got := drain(subscriber); len(got) != 1 || got[0] != "new framework" {
+		t.Fatalf("pattern subscriber got %v, want [new framework]", got)
+	}
+}
+
+func TestOverlappingSubscriptionsDeliverOnce(t *testing.T) {
+	ts := newTestService("tech.ai")
+	subscriber := NewSubscriberService().CreateBufferedSubscriber(1, 10)
+	ts.AddSubscriber("tech.ai", subscriber, model.DropNewest)
+	ts.AddSubscriber("tech.*", subscriber, model.DropNewest)
+	ts.AddSubscriber("*.ai", subscriber, model.DropNewest)
+
+	publishAll(t, ts, "tech.ai", "once")
+	if got := drain(subscriber); len(got) != 1 {
+		t.Fatalf("got %v, want the message once", got)
+	}
+}
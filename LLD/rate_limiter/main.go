@@ -1,15 +1,71 @@
 package ratelimiter
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type RateLimiter interface {
 	Allow() bool
+	// Reset forgets every request seen so far
+	Reset()
+}
+
+// Reason explains a decision, RetryAfter estimates how long a denied
+// caller should wait before a request can be admitted
+type Reason struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// Stats counts the decisions a limiter made
+type Stats struct {
+	Allowed int64
+	Denied  int64
+}
+
+// decisions is embedded in every limiter to track its allow/deny counts
+type decisions struct {
+	allowed    atomic.Int64
+	denied     atomic.Int64
+	onDecision atomic.Pointer[func(allowed bool)]
+}
+
+// record counts the decision and reports it to the OnDecision callback,
+// limiters call it after releasing their lock
+func (d *decisions) record(allowed bool) bool {
+	if allowed {
+		d.allowed.Add(1)
+	} else {
+		d.denied.Add(1)
+	}
+	if fn := d.onDecision.Load(); fn != nil {
+		(*fn)(allowed)
+	}
+	return allowed
+}
+
+// Stats returns the decisions made since the limiter was created
+func (d *decisions) Stats() Stats {
+	return Stats{Allowed: d.allowed.Load(), Denied: d.denied.Load()}
+}
+
+// OnDecision registers fn to be called after every Allow, nil removes it
+func (d *decisions) OnDecision(fn func(allowed bool)) {
+	if fn == nil {
+		d.onDecision.Store(nil)
+		return
+	}
+	d.onDecision.Store(&fn)
 }
 
 type SlidingWindowLimiter struct {
+	decisions
 	mu         sync.Mutex
 	windowSize time.Duration
 	limit      int
@@ -25,6 +81,19 @@ func NewSlidingWindowLimiter(limit int, windowSize time.Duration) *SlidingWindow
 }
 
 func (r *SlidingWindowLimiter) Allow() bool {
+	allowed, _ := r.AllowWithReason()
+	return allowed
+}
+
+// AllowWithReason is Allow, when denied it also reports the time until
+// the request holding the slot leaves the window
+func (r *SlidingWindowLimiter) AllowWithReason() (bool, Reason) {
+	reason := r.allow()
+	r.record(reason.Allowed)
+	return reason.Allowed, reason
+}
+
+func (r *SlidingWindowLimiter) allow() Reason {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -41,13 +110,35 @@ func (r *SlidingWindowLimiter) Allow() bool {
 
 	if len(r.timestamps) < r.limit {
 		r.timestamps = append(r.timestamps, now)
-		return true
+		return Reason{Allowed: true}
 	}
 
-	return false
+	if r.limit <= 0 {
+		return Reason{RetryAfter: r.windowSize}
+	}
+	// a lowered limit needs more than the oldest request to leave
+	blocking := r.timestamps[len(r.timestamps)-r.limit]
+	return Reason{RetryAfter: blocking.Add(r.windowSize).Sub(now)}
+}
+
+// SetLimit changes the number of requests admitted per window, requests
+// already in the window keep counting against the new limit
+func (r *SlidingWindowLimiter) SetLimit(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.limit = n
+}
+
+func (r *SlidingWindowLimiter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timestamps = r.timestamps[:0]
 }
 
 type TokenBucketLimiter struct {
+	decisions
 	mu         sync.Mutex
 	tokens     int
 	capacity   int
@@ -64,10 +155,8 @@ func NewTokenBucketLimiter(rate int, capacity int) *TokenBucketLimiter {
 	}
 }
 
-func (t *TokenBucketLimiter) Allow() bool {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
+// refill adds the tokens earned since the last refill, callers hold t.mu
+func (t *TokenBucketLimiter) refill() {
 	now := time.Now()
 	elapsed := now.Sub(t.lastRefill).Seconds()
 	newTokens := int(elapsed * float64(t.rate))
@@ -76,11 +165,317 @@ func (t *TokenBucketLimiter) Allow() bool {
 		t.tokens = min(t.capacity, t.tokens+newTokens)
 		t.lastRefill = now
 	}
+}
+
+func (t *TokenBucketLimiter) Allow() bool {
+	allowed, _ := t.AllowWithReason()
+	return allowed
+}
+
+// AllowWithReason is Allow, when denied it also reports the time until
+// the next token is added
+func (t *TokenBucketLimiter) AllowWithReason() (bool, Reason) {
+	reason := t.allow()
+	t.record(reason.Allowed)
+	return reason.Allowed, reason
+}
+
+func (t *TokenBucketLimiter) allow() Reason {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.refill()
 
 	if t.tokens > 0 {
 		t.tokens--
-		return true
+		return Reason{Allowed: true}
+	}
+
+	if t.rate <= 0 {
+		return Reason{}
 	}
+	perToken := time.Second / time.Duration(t.rate)
+	return Reason{RetryAfter: max(0, perToken-time.Since(t.lastRefill))}
+}
+
+// SetCapacity changes the bucket size, tokens above the new capacity are dropped
+func (t *TokenBucketLimiter) SetCapacity(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.refill()
+	t.capacity = n
+	t.tokens = min(t.tokens, n)
+}
+
+// SetRate changes the refill rate, tokens earned so far use the old rate
+func (t *TokenBucketLimiter) SetRate(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.refill()
+	t.rate = n
+}
+
+func (t *TokenBucketLimiter) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tokens = t.capacity
+	t.lastRefill = time.Now()
+}
+
+// FixedWindowLimiter admits limit requests per window, the count starts
+// over at the beginning of every window
+type FixedWindowLimiter struct {
+	decisions
+	mu          sync.Mutex
+	windowSize  time.Duration
+	limit       int
+	count       int
+	windowStart time.Time
+}
+
+func NewFixedWindowLimiter(limit int, windowSize time.Duration) *FixedWindowLimiter {
+	return &FixedWindowLimiter{
+		windowSize:  windowSize,
+		limit:       limit,
+		windowStart: time.Now(),
+	}
+}
 
-	return false
+func (f *FixedWindowLimiter) Allow() bool {
+	allowed, _ := f.AllowWithReason()
+	return allowed
+}
+
+// AllowWithReason is Allow, when denied it also reports the time until
+// the next window starts
+func (f *FixedWindowLimiter) AllowWithReason() (bool, Reason) {
+	reason := f.allow()
+	f.record(reason.Allowed)
+	return reason.Allowed, reason
+}
+
+func (f *FixedWindowLimiter) allow() Reason {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.windowStart) >= f.windowSize {
+		f.windowStart = now
+		f.count = 0
+	}
+
+	if f.count < f.limit {
+		f.count++
+		return Reason{Allowed: true}
+	}
+
+	return Reason{RetryAfter: f.windowStart.Add(f.windowSize).Sub(now)}
+}
+
+// SetLimit changes the number of requests admitted per window
+func (f *FixedWindowLimiter) SetLimit(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.limit = n
+}
+
+func (f *FixedWindowLimiter) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.count = 0
+	f.windowStart = time.Now()
+}
+
+// LeakyBucketLimiter fills a bucket by one per request and drains it at a
+// constant rate, requests that would overflow the bucket are rejected
+type LeakyBucketLimiter struct {
+	decisions
+	mu       sync.Mutex
+	level    float64
+	capacity int
+	rate     int // leaked per second
+	lastLeak time.Time
+}
+
+func NewLeakyBucketLimiter(rate int, capacity int) *LeakyBucketLimiter {
+	return &LeakyBucketLimiter{
+		rate:     rate,
+		capacity: capacity,
+		lastLeak: time.Now(),
+	}
+}
+
+func (l *LeakyBucketLimiter) Allow() bool {
+	allowed, _ := l.AllowWithReason()
+	return allowed
+}
+
+// AllowWithReason is Allow, when denied it also reports the time until
+// enough has leaked for one more request
+func (l *LeakyBucketLimiter) AllowWithReason() (bool, Reason) {
+	reason := l.allow()
+	l.record(reason.Allowed)
+	return reason.Allowed, reason
+}
+
+func (l *LeakyBucketLimiter) allow() Reason {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.level = max(0, l.level-now.Sub(l.lastLeak).Seconds()*float64(l.rate))
+	l.lastLeak = now
+
+	if l.level+1 <= float64(l.capacity) {
+		l.level++
+		return Reason{Allowed: true}
+	}
+
+	if l.rate <= 0 {
+		return Reason{}
+	}
+	overflow := l.level + 1 - float64(l.capacity)
+	return Reason{RetryAfter: time.Duration(overflow / float64(l.rate) * float64(time.Second))}
+}
+
+func (l *LeakyBucketLimiter) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.level = 0
+	l.lastLeak = time.Now()
+}
+
+const (
+	KindSlidingWindow = "sliding_window"
+	KindTokenBucket   = "token_bucket"
+	KindFixedWindow   = "fixed_window"
+	KindLeakyBucket   = "leaky_bucket"
+)
+
+// LimiterOptions configures NewLimiter. Window limiters need Limit and
+// Window, bucket limiters need Rate and Capacity.
+type LimiterOptions struct {
+	Limit    int
+	Window   time.Duration
+	Rate     int
+	Capacity int
+}
+
+// NewLimiter creates the limiter of the given kind
+func NewLimiter(kind string, opts LimiterOptions) (RateLimiter, error) {
+	switch kind {
+	case KindSlidingWindow, KindFixedWindow:
+		if opts.Limit <= 0 || opts.Window <= 0 {
+			return nil, fmt.Errorf("%s limiter needs a positive limit and window", kind)
+		}
+		if kind == KindSlidingWindow {
+			return NewSlidingWindowLimiter(opts.Limit, opts.Window), nil
+		}
+		return NewFixedWindowLimiter(opts.Limit, opts.Window), nil
+	case KindTokenBucket, KindLeakyBucket:
+		if opts.Rate <= 0 || opts.Capacity <= 0 {
+			return nil, fmt.Errorf("%s limiter needs a positive rate and capacity", kind)
+		}
+		if kind == KindTokenBucket {
+			return NewTokenBucketLimiter(opts.Rate, opts.Capacity), nil
+		}
+		return NewLeakyBucketLimiter(opts.Rate, opts.Capacity), nil
+	default:
+		return nil, fmt.Errorf("unknown limiter kind %q", kind)
+	}
+}
+
+// reasoner is implemented by the limiters that can tell how long a
+// denied caller should wait
+type reasoner interface {
+	AllowWithReason() (bool, Reason)
+}
+
+// defaultRetryAfter is sent for limiters that can't estimate the wait
+const defaultRetryAfter = time.Second
+
+// admit asks l for a decision and, when denied, how long to wait
+func admit(l RateLimiter) (bool, time.Duration) {
+	if rl, ok := l.(reasoner); ok {
+		allowed, reason := rl.AllowWithReason()
+		return allowed, reason.RetryAfter
+	}
+	return l.Allow(), defaultRetryAfter
+}
+
+// reject answers 429, Retry-After holds the wait rounded up to whole seconds
+func reject(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := max(1, int((retryAfter+time.Second-1)/time.Second))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+// Middleware rejects requests with 429 Too Many Requests once l denies them
+func Middleware(l RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowed, retryAfter := admit(l); !allowed {
+				reject(w, retryAfter)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// keyedEntry is one client's limiter and when it last sent a request
+type keyedEntry struct {
+	limiter  RateLimiter
+	lastSeen time.Time
+}
+
+// KeyedMiddleware limits every client IP separately, newLimiter creates
+// the limiter for an IP the first time it's seen. An IP idle for idleTTL
+// loses its limiter, idle entries are swept at most once per idleTTL, so
+// only the IPs seen in the last two idleTTLs are kept. idleTTL should be
+// at least the limiter's window, a returning IP starts with a fresh limiter.
+func KeyedMiddleware(newLimiter func() RateLimiter, idleTTL time.Duration) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	limiters := make(map[string]*keyedEntry)
+	lastSweep := time.Now()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+
+			mu.Lock()
+			now := time.Now()
+			if now.Sub(lastSweep) >= idleTTL {
+				for key, e := range limiters {
+					if now.Sub(e.lastSeen) >= idleTTL {
+						delete(limiters, key)
+					}
+				}
+				lastSweep = now
+			}
+			e, ok := limiters[ip]
+			if !ok {
+				e = &keyedEntry{limiter: newLimiter()}
+				limiters[ip] = e
+			}
+			e.lastSeen = now
+			l := e.limiter
+			mu.Unlock()
+
+			if allowed, retryAfter := admit(l); !allowed {
+				reject(w, retryAfter)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
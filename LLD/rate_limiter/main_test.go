@@ -0,0 +1,234 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// serve sends one request from addr through h and returns the recorded response
+func serve(h http.Handler, addr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = addr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareRejectsPastLimit(t *testing.T) {
+	h := Middleware(NewSlidingWindowLimiter(2, 5*time.Second))(okHandler)
+
+	for i := 0; i < 2; i++ {
+		if rec := serve(h, "10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, rec.Code)
+		}
+	}
+	rec := serve(h, "10.0.0.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", rec.Code)
+	}
+	// the first request leaves the 5s window in just under 5s
+	if got := rec.Header().Get("Retry-After"); got != "5" {
+		t.Fatalf("Retry-After = %q, want 5", got)
+	}
+}
+
+func TestMiddlewareRetryAfterFollowsRefill(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter RateLimiter
+		want    string
+	}{
+		{"token bucket", NewTokenBucketLimiter(1, 1), "1"},
+		{"fixed window", NewFixedWindowLimiter(1, 3*time.Second), "3"},
+		{"leaky bucket", NewLeakyBucketLimiter(1, 1), "1"},
+	}
+	for _, tt := range tests {
+		h := Middleware(tt.limiter)(okHandler)
+		serve(h, "10.0.0.1:1234")
+		rec := serve(h, "10.0.0.1:1234")
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != tt.want {
+			t.Errorf("%s: status %d, Retry-After %q, want 429 and %s", tt.name, rec.Code, rec.Header().Get("Retry-After"), tt.want)
+		}
+	}
+}
+
+func TestKeyedMiddlewareLimitsEachIP(t *testing.T) {
+	h := KeyedMiddleware(func() RateLimiter { return NewFixedWindowLimiter(1, time.Minute) }, time.Hour)(okHandler)
+
+	if rec := serve(h, "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("first IP: status %d, want 200", rec.Code)
+	}
+	if rec := serve(h, "10.0.0.1:5678"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("first IP, other port: status %d, want 429", rec.Code)
+	}
+	if rec := serve(h, "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("second IP: status %d, want 200", rec.Code)
+	}
+}
+
+func TestKeyedMiddlewareEvictsIdleIPs(t *testing.T) {
+	h := KeyedMiddleware(func() RateLimiter { return NewFixedWindowLimiter(1, time.Minute) }, 20*time.Millisecond)(okHandler)
+
+	serve(h, "10.0.0.1:1234")
+	if rec := serve(h, "10.0.0.1:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("before going idle: status %d, want 429", rec.Code)
+	}
+	time.Sleep(30 * time.Millisecond)
+	// another client's request sweeps the idle IP, which comes back with a fresh limiter
+	serve(h, "10.0.0.2:1234")
+	if rec := serve(h, "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("after going idle: status %d, want 200", rec.Code)
+	}
+}
+
+func TestNewLimiterBuildsEachKind(t *testing.T) {
+	tests := []struct {
+		kind string
+		opts LimiterOptions
+		want RateLimiter
+	}{
+		{KindSlidingWindow, LimiterOptions{Limit: 1, Window: time.Second}, &SlidingWindowLimiter{}},
+		{KindFixedWindow, LimiterOptions{Limit: 1, Window: time.Second}, &FixedWindowLimiter{}},
+		{KindTokenBucket, LimiterOptions{Rate: 1, Capacity: 1}, &TokenBucketLimiter{}},
+		{KindLeakyBucket, LimiterOptions{Rate: 1, Capacity: 1}, &LeakyBucketLimiter{}},
+	}
+	for _, tt := range tests {
+		l, err := NewLimiter(tt.kind, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.kind, err)
+		}
+		if gotType, wantType := fmt.Sprintf("%T", l), fmt.Sprintf("%T", tt.want); gotType != wantType {
+			t.Errorf("%s: got %s, want %s", tt.kind, gotType, wantType)
+		}
+		if !l.Allow() || l.Allow() {
+			t.Errorf("%s: want one request admitted then a denial", tt.kind)
+		}
+	}
+}
+
+func TestNewLimiterRejectsBadOptions(t *testing.T) {
+	tests := []struct {
+		kind string
+		opts LimiterOptions
+	}{
+		{"round_robin", LimiterOptions{Limit: 1, Window: time.Second}},
+		{KindSlidingWindow, LimiterOptions{Limit: 1}},
+		{KindFixedWindow, LimiterOptions{Window: time.Second}},
+		{KindTokenBucket, LimiterOptions{Capacity: 1}},
+		{KindLeakyBucket, LimiterOptions{Rate: 1, Capacity: -1}},
+	}
+	for _, tt := range tests {
+		if l, err := NewLimiter(tt.kind, tt.opts); err == nil {
+			t.Errorf("%s with %+v: got %T, want an error", tt.kind, tt.opts, l)
+		}
+	}
+}
+
+func TestSetLimitAdmitsPreviouslyDenied(t *testing.T) {
+	sw := NewSlidingWindowLimiter(2, time.Minute)
+	sw.Allow()
+	sw.Allow()
+	if sw.Allow() {
+		t.Fatal("sliding window admitted a third request at limit 2")
+	}
+	sw.SetLimit(4)
+	if !sw.Allow() || !sw.Allow() || sw.Allow() {
+		t.Fatal("sliding window raised to 4 should admit exactly two more")
+	}
+
+	fw := NewFixedWindowLimiter(1, time.Minute)
+	fw.Allow()
+	fw.SetLimit(2)
+	if !fw.Allow() || fw.Allow() {
+		t.Fatal("fixed window raised to 2 should admit exactly one more")
+	}
+}
+
+func TestTokenBucketSetters(t *testing.T) {
+	tb := NewTokenBucketLimiter(1, 5)
+	tb.SetCapacity(2)
+	if !tb.Allow() || !tb.Allow() || tb.Allow() {
+		t.Fatal("lowering capacity to 2 should clamp the bucket to two tokens")
+	}
+
+	tb.SetRate(1000)
+	time.Sleep(10 * time.Millisecond)
+	if !tb.Allow() {
+		t.Fatal("raised rate should refill the bucket within 10ms")
+	}
+}
+
+func TestResetAdmitsAgain(t *testing.T) {
+	limiters := map[string]RateLimiter{
+		"sliding window": NewSlidingWindowLimiter(1, time.Minute),
+		"fixed window":   NewFixedWindowLimiter(1, time.Minute),
+		"token bucket":   NewTokenBucketLimiter(1, 1),
+		"leaky bucket":   NewLeakyBucketLimiter(1, 1),
+	}
+	for name, l := range limiters {
+		l.Allow()
+		if l.Allow() {
+			t.Fatalf("%s: not exhausted after one request", name)
+		}
+		l.Reset()
+		if !l.Allow() {
+			t.Errorf("%s: denied right after Reset", name)
+		}
+	}
+}
+
+func TestStatsAndOnDecision(t *testing.T) {
+	sw := NewSlidingWindowLimiter(2, time.Minute)
+	var seen []bool
+	sw.OnDecision(func(allowed bool) {
+		// takes the limiter's lock, deadlocks if the callback runs under it
+		sw.SetLimit(2)
+		seen = append(seen, allowed)
+	})
+	for i := 0; i < 5; i++ {
+		sw.Allow()
+	}
+	sw.AllowWithReason()
+
+	if got := sw.Stats(); got != (Stats{Allowed: 2, Denied: 4}) {
+		t.Fatalf("stats = %+v, want 2 allowed and 4 denied", got)
+	}
+	if want := []bool{true, true, false, false, false, false}; fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("callback saw %v, want %v", seen, want)
+	}
+
+	sw.OnDecision(nil)
+	sw.Allow()
+	if len(seen) != 6 || sw.Stats().Denied != 5 {
+		t.Fatalf("removed callback still called, or denial not counted")
+	}
+}
+
+func TestAllowWithReasonReportsWait(t *testing.T) {
+	sw := NewSlidingWindowLimiter(1, 200*time.Millisecond)
+	if allowed, reason := sw.AllowWithReason(); !allowed || !reason.Allowed || reason.RetryAfter != 0 {
+		t.Fatalf("first call: %t, %+v, want admitted with no wait", allowed, reason)
+	}
+	time.Sleep(50 * time.Millisecond)
+	allowed, reason := sw.AllowWithReason()
+	if allowed || reason.Allowed {
+		t.Fatal("second call admitted, want denied")
+	}
+	// the first request leaves the window roughly 150ms from now
+	if reason.RetryAfter <= 0 || reason.RetryAfter > 150*time.Millisecond {
+		t.Fatalf("RetryAfter = %v, want within (0, 150ms]", reason.RetryAfter)
+	}
+
+	tb := NewTokenBucketLimiter(10, 1)
+	tb.Allow()
+	if _, reason := tb.AllowWithReason(); reason.RetryAfter <= 0 || reason.RetryAfter > 100*time.Millisecond {
+		t.Fatalf("token bucket RetryAfter = %v, want within (0, 100ms]", reason.RetryAfter)
+	}
+}
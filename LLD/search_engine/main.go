@@ -1,47 +1,204 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // ====== Document ======
 type Document struct {
-	ID       int
-	Text     string
-	Category string
+	ID        int
+	Text      string
+	Category  string
+	CreatedAt time.Time // zero when unknown
 }
 
 // ====== Indexer ======
 type Indexer interface {
 	Index(docs []Document)
 	Search(keyword string) []int
+	// Remove drops the entries of a previously indexed document
+	Remove(doc Document)
+}
+
+// ====== Bloom Filter ======
+type BloomFilter struct {
+	bits   []bool
+	hashes int
+}
+
+// NewBloomFilter sizes the filter for the expected number of terms
+// and the wanted false-positive rate.
+func NewBloomFilter(expectedTerms int, falsePositiveRate float64) *BloomFilter {
+	if expectedTerms < 1 {
+		expectedTerms = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	m := math.Ceil(-float64(expectedTerms) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(expectedTerms) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{bits: make([]bool, int(m)), hashes: int(k)}
+}
+
+// positions derives the bit positions of a term by double hashing
+func (b *BloomFilter) positions(term string) []int {
+	h := fnv.New64a()
+	h.Write([]byte(term))
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)
+
+	positions := make([]int, b.hashes)
+	for i := range positions {
+		positions[i] = int((uint64(h1) + uint64(i)*uint64(h2)) % uint64(len(b.bits)))
+	}
+	return positions
+}
+
+func (b *BloomFilter) Add(term string) {
+	for _, pos := range b.positions(term) {
+		b.bits[pos] = true
+	}
+}
+
+// MayContain returns false only if the term was definitely never added
+func (b *BloomFilter) MayContain(term string) bool {
+	for _, pos := range b.positions(term) {
+		if !b.bits[pos] {
+			return false
+		}
+	}
+	return true
 }
 
 type InvertedIndexer struct {
 	index map[string][]int
+	bloom *BloomFilter // optional, nil skips the existence check
 }
 
 func NewInvertedIndexer() *InvertedIndexer {
 	return &InvertedIndexer{index: make(map[string][]int)}
 }
 
+// NewBloomInvertedIndexer returns an indexer that checks a Bloom filter
+// before hitting the posting lists
+func NewBloomInvertedIndexer(expectedTerms int, falsePositiveRate float64) *InvertedIndexer {
+	return &InvertedIndexer{
+		index: make(map[string][]int),
+		bloom: NewBloomFilter(expectedTerms, falsePositiveRate),
+	}
+}
+
+// terms returns the distinct lowercased words of text
+func terms(text string) []string {
+	var words []string
+	seen := make(map[string]bool)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if !seen[word] {
+			words = append(words, word)
+			seen[word] = true
+		}
+	}
+	return words
+}
+
 func (i *InvertedIndexer) Index(docs []Document) {
 	for _, doc := range docs {
-		words := strings.Fields(strings.ToLower(doc.Text))
-		seen := make(map[string]bool)
-		for _, word := range words {
-			if !seen[word] {
-				i.index[word] = append(i.index[word], doc.ID)
-				seen[word] = true
+		for _, word := range terms(doc.Text) {
+			i.index[word] = append(i.index[word], doc.ID)
+			if i.bloom != nil {
+				i.bloom.Add(word)
 			}
 		}
 	}
 }
 
+// Remove drops doc from the posting lists of its words. Bloom filter bits
+// can't be cleared, a removed term only costs a posting list lookup.
+func (i *InvertedIndexer) Remove(doc Document) {
+	for _, word := range terms(doc.Text) {
+		postings := i.index[word][:0]
+		for _, id := range i.index[word] {
+			if id != doc.ID {
+				postings = append(postings, id)
+			}
+		}
+		if len(postings) == 0 {
+			delete(i.index, word)
+		} else {
+			i.index[word] = postings
+		}
+	}
+}
+
+// Search returns a copy of the keyword's posting list, callers sort
+// the results and must not reorder the index
 func (i *InvertedIndexer) Search(keyword string) []int {
-	return i.index[strings.ToLower(keyword)]
+	keyword = strings.ToLower(keyword)
+	if i.bloom != nil && !i.bloom.MayContain(keyword) {
+		return nil
+	}
+	return append([]int(nil), i.index[keyword]...)
+}
+
+// levenshtein returns the number of single character edits turning a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// SearchFuzzy returns the sorted IDs of the documents containing a term within
+// maxDistance edits of keyword. It scans every indexed term.
+func (i *InvertedIndexer) SearchFuzzy(keyword string, maxDistance int) []int {
+	keyword = strings.ToLower(keyword)
+	seen := make(map[int]bool)
+	var ids []int
+	for term, postings := range i.index {
+		if levenshtein(term, keyword) > maxDistance {
+			continue
+		}
+		for _, id := range postings {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// FuzzyIndexer is implemented by indexers that tolerate typos
+type FuzzyIndexer interface {
+	SearchFuzzy(keyword string, maxDistance int) []int
 }
 
 // ====== Category Indexer (Keyword-style) ======
@@ -65,43 +222,112 @@ func (c *CategoryIndexer) Index(docs []Document) {
 	}
 }
 
+func (c *CategoryIndexer) Remove(doc Document) {
+	cat := strings.ToLower(doc.Category)
+	delete(c.categoryIndex[cat], doc.ID)
+	if len(c.categoryIndex[cat]) == 0 {
+		delete(c.categoryIndex, cat)
+	}
+}
+
+// GetDocsByCategories returns the documents in any of the categories.
+// Categories are hierarchical, "programming" also matches "programming/go".
 func (c *CategoryIndexer) GetDocsByCategories(categories []string) map[int]struct{} {
 	result := make(map[int]struct{})
 	for _, cat := range categories {
-		for id := range c.categoryIndex[strings.ToLower(cat)] {
-			result[id] = struct{}{}
+		cat = strings.ToLower(cat)
+		for indexed, docs := range c.categoryIndex {
+			if indexed != cat && !strings.HasPrefix(indexed, cat+"/") {
+				continue
+			}
+			for id := range docs {
+				result[id] = struct{}{}
+			}
 		}
 	}
 	return result
 }
 
+// CountByCategory returns how many of the given documents fall in each category
+func (c *CategoryIndexer) CountByCategory(ids []int) map[string]int {
+	counts := make(map[string]int)
+	for cat, docs := range c.categoryIndex {
+		for _, id := range ids {
+			if _, exists := docs[id]; exists {
+				counts[cat]++
+			}
+		}
+	}
+	return counts
+}
+
 // ====== Ranking Strategy Pattern ======
 type RankingStrategy interface {
-	Rank(results []int, docs map[int]Document, keyword string) []int
+	// Rank orders the results, keywords are the terms they were matched by
+	Rank(results []int, docs map[int]Document, keywords []string) []int
 }
 
-type ByDocSize struct{}
-
-func (r *ByDocSize) Rank(results []int, docs map[int]Document, keyword string) []int {
-	sort.Slice(results, func(i, j int) bool {
-		return len(docs[results[i]].Text) < len(docs[results[j]].Text)
+// rankBy sorts the results by score, best first when descending,
+// documents with the same score are ordered by ID
+func rankBy(results []int, score func(id int) int, descending bool) []int {
+	sort.SliceStable(results, func(i, j int) bool {
+		si, sj := score(results[i]), score(results[j])
+		if si != sj {
+			if descending {
+				return si > sj
+			}
+			return si < sj
+		}
+		return results[i] < results[j]
 	})
 	return results
 }
 
+type ByDocSize struct{}
+
+func (r *ByDocSize) Rank(results []int, docs map[int]Document, keywords []string) []int {
+	return rankBy(results, func(id int) int {
+		return len(docs[id].Text)
+	}, false)
+}
+
+// ByKeywordFrequency ranks by the occurrences of all the keywords together
 type ByKeywordFrequency struct{}
 
-func (r *ByKeywordFrequency) Rank(results []int, docs map[int]Document, keyword string) []int {
-	keyword = strings.ToLower(keyword)
-	sort.Slice(results, func(i, j int) bool {
-		return strings.Count(strings.ToLower(docs[results[i]].Text), keyword) >
-			strings.Count(strings.ToLower(docs[results[j]].Text), keyword)
+func (r *ByKeywordFrequency) Rank(results []int, docs map[int]Document, keywords []string) []int {
+	return rankBy(results, func(id int) int {
+		text := strings.ToLower(docs[id].Text)
+		count := 0
+		for _, keyword := range keywords {
+			if keyword != "" {
+				count += strings.Count(text, strings.ToLower(keyword))
+			}
+		}
+		return count
+	}, true)
+}
+
+// ByRecency ranks the newest documents first, undated ones last
+type ByRecency struct{}
+
+func (r *ByRecency) Rank(results []int, docs map[int]Document, keywords []string) []int {
+	sort.SliceStable(results, func(i, j int) bool {
+		ti, tj := docs[results[i]].CreatedAt, docs[results[j]].CreatedAt
+		if !ti.Equal(tj) {
+			if ti.IsZero() || tj.IsZero() {
+				return tj.IsZero()
+			}
+			return ti.After(tj)
+		}
+		return results[i] < results[j]
 	})
 	return results
 }
 
 func GetRankingStrategy(method string) RankingStrategy {
 	switch method {
+	case "recency":
+		return &ByRecency{}
 	case "size":
 		return &ByDocSize{}
 	case "frequency":
@@ -143,6 +369,235 @@ func (f *IndexedCategoryFilter) Filter(ids []int, docs map[int]Document) []int {
 	return filtered
 }
 
+// ====== Query ======
+type Query struct {
+	Keyword            string    // optional, empty matches every document
+	Expr               QueryExpr // optional boolean expression from ParseQuery, used instead of Keyword
+	Categories         []string  // optional, documents must be in one of these
+	ExcludedCategories []string  // documents in these are dropped
+}
+
+// ====== Boolean Query Parser ======
+// QueryExpr is a node of a parsed boolean query, evaluated over the index
+type QueryExpr interface {
+	// eval returns the matching IDs, all is every indexed document for NOT
+	eval(indexer Indexer, all map[int]bool) map[int]bool
+	// terms returns the keywords a match can contain, negated ones are left out
+	terms() []string
+}
+
+type termExpr struct{ term string }
+
+type andExpr struct{ left, right QueryExpr }
+
+type orExpr struct{ left, right QueryExpr }
+
+type notExpr struct{ child QueryExpr }
+
+func (e *termExpr) eval(indexer Indexer, all map[int]bool) map[int]bool {
+	ids := make(map[int]bool)
+	for _, id := range indexer.Search(e.term) {
+		ids[id] = true
+	}
+	return ids
+}
+
+func (e *termExpr) terms() []string { return []string{e.term} }
+
+func (e *andExpr) terms() []string { return append(e.left.terms(), e.right.terms()...) }
+
+func (e *orExpr) terms() []string { return append(e.left.terms(), e.right.terms()...) }
+
+func (e *notExpr) terms() []string { return nil }
+
+func (e *andExpr) eval(indexer Indexer, all map[int]bool) map[int]bool {
+	left, right := e.left.eval(indexer, all), e.right.eval(indexer, all)
+	ids := make(map[int]bool)
+	for id := range left {
+		if right[id] {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+func (e *orExpr) eval(indexer Indexer, all map[int]bool) map[int]bool {
+	ids := e.left.eval(indexer, all)
+	for id := range e.right.eval(indexer, all) {
+		ids[id] = true
+	}
+	return ids
+}
+
+func (e *notExpr) eval(indexer Indexer, all map[int]bool) map[int]bool {
+	excluded := e.child.eval(indexer, all)
+	ids := make(map[int]bool)
+	for id := range all {
+		if !excluded[id] {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// ParseQuery parses keywords joined by AND, OR, NOT and parentheses, eg.
+// "go AND (concurrency OR efficient) AND NOT parallelism". Operators are
+// uppercase, NOT binds tightest and AND binds tighter than OR.
+func ParseQuery(s string) (Query, error) {
+	p := &queryParser{tokens: tokenizeQuery(s)}
+	if len(p.tokens) == 0 {
+		return Query{}, fmt.Errorf("empty query")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return Query{}, err
+	}
+	if tok, ok := p.peek(); ok {
+		if tok == ")" {
+			return Query{}, fmt.Errorf("unbalanced parentheses: unexpected ')' at token %d", p.pos+1)
+		}
+		return Query{}, fmt.Errorf("expected AND or OR before %q at token %d", tok, p.pos+1)
+	}
+	return Query{Expr: expr}, nil
+}
+
+// tokenizeQuery splits on whitespace, parentheses are tokens of their own
+func tokenizeQuery(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *queryParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) parseOr() (QueryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for tok, ok := p.peek(); ok && tok == "OR"; tok, ok = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (QueryExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for tok, ok := p.peek(); ok && tok == "AND"; tok, ok = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (QueryExpr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		if p.pos > 0 {
+			return nil, fmt.Errorf("expected a term after %s", p.tokens[p.pos-1])
+		}
+		return nil, fmt.Errorf("expected a term")
+	}
+	p.pos++
+	switch tok {
+	case "NOT":
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{child: child}, nil
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next != ")" {
+			return nil, fmt.Errorf("unbalanced parentheses: missing ')'")
+		}
+		p.pos++
+		return expr, nil
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("expected a term at token %d, got %q", p.pos, tok)
+	}
+	return &termExpr{term: tok}, nil
+}
+
+// ====== Snippets ======
+const (
+	snippetWindow = 20 // characters shown on each side of the match
+	snippetMarker = "**"
+)
+
+type Result struct {
+	Document
+	Snippet string
+}
+
+// buildSnippet returns the text around the first match of keyword
+// with the matched term surrounded by markers. Matching ignores case and
+// the window is counted in runes, so multi-byte characters aren't cut.
+func buildSnippet(text, keyword string) string {
+	runes := []rune(text)
+	idx := matchFold(runes, keyword)
+	if idx < 0 {
+		if len(runes) > 2*snippetWindow {
+			return string(runes[:2*snippetWindow]) + "..."
+		}
+		return text
+	}
+	end := idx + utf8.RuneCountInString(keyword)
+	from := max(idx-snippetWindow, 0)
+	to := min(end+snippetWindow, len(runes))
+
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("...")
+	}
+	sb.WriteString(string(runes[from:idx]))
+	sb.WriteString(snippetMarker + string(runes[idx:end]) + snippetMarker)
+	sb.WriteString(string(runes[end:to]))
+	if to < len(runes) {
+		sb.WriteString("...")
+	}
+	return sb.String()
+}
+
+// matchFold returns the rune index of the first case-insensitive match of
+// keyword in runes, -1 if there is none
+func matchFold(runes []rune, keyword string) int {
+	n := utf8.RuneCountInString(keyword)
+	if n == 0 {
+		return -1
+	}
+	for i := 0; i+n <= len(runes); i++ {
+		if strings.EqualFold(string(runes[i:i+n]), keyword) {
+			return i
+		}
+	}
+	return -1
+}
+
 // ====== Search Engine ======
 type SearchEngine struct {
 	documents       map[int]Document
@@ -166,11 +621,31 @@ func (s *SearchEngine) AddDocuments(docs []Document) {
 	s.categoryIndexer.Index(docs)
 }
 
+// UpdateDocument replaces the document with the same ID, or adds it if it's new,
+// so the old text and category no longer match
+func (s *SearchEngine) UpdateDocument(doc Document) {
+	if old, exists := s.documents[doc.ID]; exists {
+		s.indexer.Remove(old)
+		s.categoryIndexer.Remove(old)
+	}
+	s.AddDocuments([]Document{doc})
+}
+
+// SearchFuzzy returns the documents matching keyword with up to maxDistance typos,
+// nil if the indexer doesn't support fuzzy search
+func (s *SearchEngine) SearchFuzzy(keyword string, maxDistance int) []int {
+	fuzzy, ok := s.indexer.(FuzzyIndexer)
+	if !ok {
+		return nil
+	}
+	return fuzzy.SearchFuzzy(keyword, maxDistance)
+}
+
 func (s *SearchEngine) Search(keyword, rankingMethod string, filter FilterStrategy) []Document {
 	ids := s.indexer.Search(keyword)
 	filtered := filter.Filter(ids, s.documents)
 	ranker := GetRankingStrategy(rankingMethod)
-	sortedIDs := ranker.Rank(filtered, s.documents, keyword)
+	sortedIDs := ranker.Rank(filtered, s.documents, []string{keyword})
 
 	results := make([]Document, 0, len(sortedIDs))
 	for _, id := range sortedIDs {
@@ -179,16 +654,173 @@ func (s *SearchEngine) Search(keyword, rankingMethod string, filter FilterStrate
 	return results
 }
 
+func (s *SearchEngine) SearchQuery(q Query, rankingMethod string) []Document {
+	var ids []int
+	if q.Expr != nil {
+		all := make(map[int]bool, len(s.documents))
+		for id := range s.documents {
+			all[id] = true
+		}
+		for id := range q.Expr.eval(s.indexer, all) {
+			ids = append(ids, id)
+		}
+	} else if q.Keyword != "" {
+		ids = append(ids, s.indexer.Search(q.Keyword)...)
+	} else {
+		for id := range s.documents {
+			ids = append(ids, id)
+		}
+	}
+	ids = NewIndexedCategoryFilter(s.categoryIndexer, q.Categories).Filter(ids, s.documents)
+
+	excluded := s.categoryIndexer.GetDocsByCategories(q.ExcludedCategories)
+	allowed := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, exists := excluded[id]; !exists {
+			allowed = append(allowed, id)
+		}
+	}
+
+	var terms []string
+	if q.Expr != nil {
+		terms = q.Expr.terms()
+	} else if q.Keyword != "" {
+		terms = []string{q.Keyword}
+	}
+	ranker := GetRankingStrategy(rankingMethod)
+	sortedIDs := ranker.Rank(allowed, s.documents, terms)
+
+	results := make([]Document, 0, len(sortedIDs))
+	for _, id := range sortedIDs {
+		results = append(results, s.documents[id])
+	}
+	return results
+}
+
+func (s *SearchEngine) SearchWithSnippets(keyword, rankingMethod string) []Result {
+	docs := s.SearchQuery(Query{Keyword: keyword}, rankingMethod)
+	results := make([]Result, 0, len(docs))
+	for _, doc := range docs {
+		results = append(results, Result{Document: doc, Snippet: buildSnippet(doc.Text, keyword)})
+	}
+	return results
+}
+
+// Facets returns the number of documents matching the keyword per category
+func (s *SearchEngine) Facets(keyword string) map[string]int {
+	return s.categoryIndexer.CountByCategory(s.indexer.Search(keyword))
+}
+
+// ====== Index Snapshots ======
+// indexSnapshot is the on-disk form of the engine, posting lists keep their order
+type indexSnapshot struct {
+	Documents   map[int]Document `json:"documents"`
+	Terms       map[string][]int `json:"terms"`
+	Categories  map[string][]int `json:"categories"`
+	BloomBits   []bool           `json:"bloom_bits,omitempty"`
+	BloomHashes int              `json:"bloom_hashes,omitempty"`
+}
+
+// SaveIndex writes the documents and both indexes to path, so a restart can
+// load them instead of re-indexing. Only an InvertedIndexer can be saved.
+func (s *SearchEngine) SaveIndex(path string) error {
+	inverted, ok := s.indexer.(*InvertedIndexer)
+	if !ok {
+		return fmt.Errorf("indexer %T can't be saved", s.indexer)
+	}
+	snapshot := indexSnapshot{
+		Documents:  s.documents,
+		Terms:      inverted.index,
+		Categories: make(map[string][]int, len(s.categoryIndexer.categoryIndex)),
+	}
+	if inverted.bloom != nil {
+		snapshot.BloomBits = inverted.bloom.bits
+		snapshot.BloomHashes = inverted.bloom.hashes
+	}
+	for cat, docs := range s.categoryIndexer.categoryIndex {
+		ids := make([]int, 0, len(docs))
+		for id := range docs {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		snapshot.Categories[cat] = ids
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("encode index: %w", err)
+	}
+	// write a temp file and rename it, a crash never leaves a partial snapshot
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("save index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("save index: %w", err)
+	}
+	return nil
+}
+
+// LoadIndex replaces the documents and indexes with the ones saved at path.
+// The engine's indexer has to be an InvertedIndexer, its Bloom filter is
+// restored from the snapshot.
+func (s *SearchEngine) LoadIndex(path string) error {
+	inverted, ok := s.indexer.(*InvertedIndexer)
+	if !ok {
+		return fmt.Errorf("indexer %T can't be loaded", s.indexer)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("load index: %w", err)
+	}
+	var snapshot indexSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("decode index %s: %w", path, err)
+	}
+
+	s.documents = snapshot.Documents
+	if s.documents == nil {
+		s.documents = make(map[int]Document)
+	}
+	inverted.index = snapshot.Terms
+	if inverted.index == nil {
+		inverted.index = make(map[string][]int)
+	}
+	inverted.bloom = nil
+	if len(snapshot.BloomBits) > 0 {
+		inverted.bloom = &BloomFilter{bits: snapshot.BloomBits, hashes: snapshot.BloomHashes}
+	}
+	// filled in place, filters hold on to the category indexer
+	s.categoryIndexer.categoryIndex = make(map[string]map[int]struct{}, len(snapshot.Categories))
+	for cat, ids := range snapshot.Categories {
+		docs := make(map[int]struct{}, len(ids))
+		for _, id := range ids {
+			docs[id] = struct{}{}
+		}
+		s.categoryIndexer.categoryIndex[cat] = docs
+	}
+	return nil
+}
+
 // ====== Main ======
 func main() {
 	docs := []Document{
-		{ID: 1, Text: "Go is expressive, concise, clean, and efficient.", Category: "programming"},
+		{ID: 1, Text: "Go is expressive, concise, clean, and efficient.", Category: "programming", CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
 		{ID: 2, Text: "Concurrency is not parallelism.", Category: "concepts"},
-		{ID: 3, Text: "Go makes it easy to build simple, reliable, and efficient software.", Category: "programming"},
+		{ID: 3, Text: "Go makes it easy to build simple, reliable, and efficient software.", Category: "programming", CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
 		{ID: 4, Text: "Software engineering is about trade-offs.", Category: "engineering"},
+		{ID: 5, Text: "Go channels make efficient concurrency simple.", Category: "programming/go"},
 	}
 
-	textIndexer := NewInvertedIndexer()
+	textIndexer := NewBloomInvertedIndexer(1000, 0.01)
 	categoryIndexer := NewCategoryIndexer()
 	searchEngine := NewSearchEngine(textIndexer, categoryIndexer)
 	searchEngine.AddDocuments(docs)
@@ -200,4 +832,62 @@ func main() {
 	for _, doc := range results {
 		fmt.Printf("Doc %d: %s (Category: %s)\n", doc.ID, doc.Text, doc.Category)
 	}
+
+	results = searchEngine.SearchQuery(Query{Keyword: "is", ExcludedCategories: []string{"concepts"}}, "size")
+
+	fmt.Println("\nSearch 'is' excluding category 'concepts':")
+	for _, doc := range results {
+		fmt.Printf("Doc %d: %s (Category: %s)\n", doc.ID, doc.Text, doc.Category)
+	}
+
+	fmt.Println("\nFacets for 'is':", searchEngine.Facets("is"))
+
+	fmt.Println("\nSnippets for 'go':")
+	for _, res := range searchEngine.SearchWithSnippets("go", "size") {
+		fmt.Printf("Doc %d: %s\n", res.ID, res.Snippet)
+	}
+
+	fmt.Println("\nNewest first for 'efficient':")
+	for _, doc := range searchEngine.SearchQuery(Query{Keyword: "efficient"}, "recency") {
+		created := "undated"
+		if !doc.CreatedAt.IsZero() {
+			created = doc.CreatedAt.Format("2006-01-02")
+		}
+		fmt.Printf("Doc %d (%s)\n", doc.ID, created)
+	}
+
+	fmt.Println("\nFuzzy 'effecient':", searchEngine.SearchFuzzy("effecient", 1))
+
+	for _, input := range []string{"go AND (concurrency OR efficient) AND NOT parallelism", "go AND", "(go OR concurrency"} {
+		query, err := ParseQuery(input)
+		if err != nil {
+			fmt.Printf("\nParse %q: %v\n", input, err)
+			continue
+		}
+		fmt.Printf("\nBoolean %q:\n", input)
+		for _, doc := range searchEngine.SearchQuery(query, "size") {
+			fmt.Printf("Doc %d: %s\n", doc.ID, doc.Text)
+		}
+	}
+
+	// A snapshot loaded into a fresh engine answers the same way
+	snapshotPath := filepath.Join(os.TempDir(), "search_index.json")
+	if err := searchEngine.SaveIndex(snapshotPath); err != nil {
+		fmt.Println(err)
+		return
+	}
+	restored := NewSearchEngine(NewInvertedIndexer(), NewCategoryIndexer())
+	if err := restored.LoadIndex(snapshotPath); err != nil {
+		fmt.Println(err)
+		return
+	}
+	identical := true
+	for _, q := range []Query{{Keyword: "go"}, {Keyword: "is", ExcludedCategories: []string{"concepts"}}, {Categories: []string{"programming"}}} {
+		identical = identical && reflect.DeepEqual(searchEngine.SearchQuery(q, "frequency"), restored.SearchQuery(q, "frequency"))
+	}
+	fmt.Println("\nRestored index gives identical results:", identical)
+
+	searchEngine.UpdateDocument(Document{ID: 2, Text: "Concurrency is about structure and design", Category: "concepts"})
+	fmt.Println("\nAfter updating doc 2, 'parallelism':", len(searchEngine.SearchQuery(Query{Keyword: "parallelism"}, "size")),
+		"'design':", len(searchEngine.SearchQuery(Query{Keyword: "design"}, "size")))
 }
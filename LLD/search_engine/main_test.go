@@ -0,0 +1,362 @@
+package main
+
+// my_search.go and search_engine.go are programs of their own, run with:
+// go test main.go main_test.go
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestEngine indexes the documents with the plain inverted index
+func newTestEngine(docs ...Document) *SearchEngine {
+	engine := NewSearchEngine(NewInvertedIndexer(), NewCategoryIndexer())
+	engine.AddDocuments(docs)
+	return engine
+}
+
+func docIDs(docs []Document) []int {
+	ids := make([]int, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+func TestSearchQueryRanksExpressionByItsTerms(t *testing.T) {
+	engine := newTestEngine(
+		Document{ID: 1, Text: "go go go"},
+		Document{ID: 2, Text: "go rust rust rust rust"},
+		Document{ID: 3, Text: "rust"},
+		Document{ID: 4, Text: "go rust java java java java java"},
+	)
+
+	q, err := ParseQuery("go OR rust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// go and rust occurrences together: 5, 4, 3, 2
+	if got, want := docIDs(engine.SearchQuery(q, "frequency")), []int{2, 1, 4, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("go OR rust ranked %v, want %v", got, want)
+	}
+
+	// negated terms don't count, java would put doc 4 first
+	q, err = ParseQuery("go AND NOT java OR rust AND NOT java")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := docIDs(engine.SearchQuery(q, "frequency")), []int{2, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("NOT java query ranked %v, want %v", got, want)
+	}
+}
+
+func TestParseQueryNestedExpression(t *testing.T) {
+	engine := newTestEngine(
+		Document{ID: 1, Text: "go concurrency parallelism"},
+		Document{ID: 2, Text: "go efficient"},
+		Document{ID: 3, Text: "go java"},
+		Document{ID: 4, Text: "rust concurrency"},
+		Document{ID: 5, Text: "go concurrency"},
+	)
+	for _, tc := range []struct {
+		query string
+		want  []int
+	}{
+		{"go AND (concurrency OR efficient) AND NOT parallelism", []int{2, 5}},
+		{"go AND (concurrency OR efficient)", []int{1, 2, 5}},
+		// without the parentheses AND binds first
+		{"go AND concurrency OR efficient", []int{1, 2, 5}},
+		{"NOT (go OR rust)", []int{}},
+		{"(rust OR java) AND NOT (go AND java)", []int{4}},
+	} {
+		q, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		got := docIDs(engine.SearchQuery(q, "frequency"))
+		sort.Ints(got)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestParseQueryRejectsMalformed(t *testing.T) {
+	for _, query := range []string{"go AND", "(go OR rust", "go )", "", "AND go", "go rust", "NOT"} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestSearchQueryRanksKeyword(t *testing.T) {
+	engine := newTestEngine(
+		Document{ID: 1, Text: "go"},
+		Document{ID: 2, Text: "go go"},
+	)
+	if got, want := docIDs(engine.SearchQuery(Query{Keyword: "go"}, "frequency")), []int{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("go ranked %v, want %v", got, want)
+	}
+}
+
+// sampleDocs is the corpus of the demo
+func sampleDocs() []Document {
+	return []Document{
+		{ID: 1, Text: "Go is expressive, concise, clean, and efficient.", Category: "programming"},
+		{ID: 2, Text: "Concurrency is not parallelism.", Category: "concepts"},
+		{ID: 3, Text: "Go makes it easy to build simple, reliable, and efficient software.", Category: "programming"},
+		{ID: 4, Text: "Software engineering is about trade-offs.", Category: "engineering"},
+		{ID: 5, Text: "Go channels make efficient concurrency simple.", Category: "programming/go"},
+	}
+}
+
+func TestSearchQueryConstraints(t *testing.T) {
+	engine := newTestEngine(sampleDocs()...)
+	tests := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		{"keyword only", Query{Keyword: "is"}, []int{1, 2, 4}},
+		{"category only", Query{Categories: []string{"engineering", "concepts"}}, []int{2, 4}},
+		{"keyword and category", Query{Keyword: "is", Categories: []string{"programming"}}, []int{1}},
+		{"keyword excluding category", Query{Keyword: "is", ExcludedCategories: []string{"concepts"}}, []int{1, 4}},
+		{"nothing", Query{}, []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		got := docIDs(engine.SearchQuery(tt.q, "recency"))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSnippetMarksMatch(t *testing.T) {
+	engine := newTestEngine(
+		Document{ID: 1, Text: "The quick brown fox jumps over the lazy dog near the river bank today"},
+		Document{ID: 2, Text: "Fox"},
+	)
+	results := engine.SearchWithSnippets("jumps", "size")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if want := "The quick brown fox **jumps** over the lazy dog n..."; results[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+	results = engine.SearchWithSnippets("river", "size")
+	if want := "...e lazy dog near the **river** bank today"; len(results) != 1 || results[0].Snippet != want {
+		t.Fatalf("got %+v, want the snippet %q", results, want)
+	}
+
+	// shorter than the window, nothing is cut
+	results = engine.SearchWithSnippets("fox", "size")
+	if len(results) != 2 || results[0].Snippet != "**Fox**" {
+		t.Fatalf("got %+v, want doc 2 first with the whole text marked", results)
+	}
+}
+
+func TestSnippetNonASCII(t *testing.T) {
+	// Ⱥ lowercases to a longer rune, the match must still line up
+	for _, tc := range []struct {
+		text, keyword, want string
+	}{
+		{"ȺȺȺȺ go", "go", "ȺȺȺȺ **go**"},
+		{"ȺȺ GO ȺȺ", "go", "ȺȺ **GO** ȺȺ"},
+		{"naïve Ⱥpple", "ⱥpple", "naïve **Ⱥpple**"},
+		{strings.Repeat("é", 30) + " naïve", "NAÏVE", "..." + strings.Repeat("é", 19) + " **naïve**"},
+		{strings.Repeat("ü", 45), "x", strings.Repeat("ü", 40) + "..."},
+	} {
+		if got := buildSnippet(tc.text, tc.keyword); got != tc.want {
+			t.Errorf("buildSnippet(%q, %q) = %q, want %q", tc.text, tc.keyword, got, tc.want)
+		}
+	}
+}
+
+func TestBloomFilterShortCircuitsAbsentTerms(t *testing.T) {
+	bloom := NewBloomFilter(100, 0.01)
+	for i := 0; i < 100; i++ {
+		bloom.Add(fmt.Sprintf("present%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		if !bloom.MayContain(fmt.Sprintf("present%d", i)) {
+			t.Fatalf("present%d reported absent", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if bloom.MayContain(fmt.Sprintf("absent%d", i)) {
+			falsePositives++
+		}
+	}
+	// 1% expected, allow some slack
+	if falsePositives > 50 {
+		t.Fatalf("%d of 1000 absent terms passed the filter", falsePositives)
+	}
+
+	engine := NewSearchEngine(NewBloomInvertedIndexer(100, 0.01), NewCategoryIndexer())
+	engine.AddDocuments(sampleDocs())
+	if got := engine.indexer.Search("go"); !reflect.DeepEqual(got, []int{1, 3, 5}) {
+		t.Fatalf("go = %v, want [1 3 5]", got)
+	}
+	if got := engine.indexer.Search("rust"); got != nil {
+		t.Fatalf("rust = %v, want nothing", got)
+	}
+}
+
+func TestFacetsCountPerCategory(t *testing.T) {
+	engine := newTestEngine(sampleDocs()...)
+	want := map[string]int{"programming": 2, "programming/go": 1}
+	if got := engine.Facets("go"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("facets for go = %v, want %v", got, want)
+	}
+	if got := engine.Facets("rust"); len(got) != 0 {
+		t.Fatalf("facets for rust = %v, want none", got)
+	}
+}
+
+func TestParentCategoryIncludesChildren(t *testing.T) {
+	docs := append(sampleDocs(), Document{ID: 6, Text: "Generics", Category: "programmingish"})
+	indexer := NewCategoryIndexer()
+	indexer.Index(docs)
+
+	tests := map[string][]int{
+		"programming":    {1, 3, 5},
+		"Programming/Go": {5},
+		"programming/g":  {},
+	}
+	for category, want := range tests {
+		var got []int
+		for id := range indexer.GetDocsByCategories([]string{category}) {
+			got = append(got, id)
+		}
+		sort.Ints(got)
+		if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("%s: got %v, want %v", category, got, want)
+		}
+	}
+}
+
+func TestUpdateDocumentReindexes(t *testing.T) {
+	engine := newTestEngine(sampleDocs()...)
+	engine.UpdateDocument(Document{ID: 2, Text: "Good design is about trade-offs.", Category: "engineering"})
+
+	if got := engine.indexer.Search("parallelism"); len(got) != 0 {
+		t.Fatalf("parallelism = %v, want no match after the update", got)
+	}
+	if got := engine.indexer.Search("design"); !reflect.DeepEqual(got, []int{2}) {
+		t.Fatalf("design = %v, want [2]", got)
+	}
+	if got := docIDs(engine.SearchQuery(Query{Categories: []string{"concepts"}}, "size")); len(got) != 0 {
+		t.Fatalf("concepts = %v, want the old category dropped", got)
+	}
+	if got := docIDs(engine.SearchQuery(Query{Categories: []string{"engineering"}}, "recency")); !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Fatalf("engineering = %v, want [2 4]", got)
+	}
+
+	engine.UpdateDocument(Document{ID: 7, Text: "brand new"})
+	if got := engine.indexer.Search("brand"); !reflect.DeepEqual(got, []int{7}) {
+		t.Fatalf("brand = %v, want the new document added", got)
+	}
+}
+
+func TestFuzzySearchToleratesTypos(t *testing.T) {
+	engine := newTestEngine(
+		Document{ID: 1, Text: "efficient code"},
+		Document{ID: 2, Text: "an efficient team"},
+		Document{ID: 3, Text: "sufficient time"},
+	)
+	if got := engine.SearchFuzzy("effecient", 1); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("effecient within 1 = %v, want [1 2]", got)
+	}
+	if got := engine.SearchFuzzy("effecient", 0); len(got) != 0 {
+		t.Fatalf("effecient within 0 = %v, want no match", got)
+	}
+	if got := engine.SearchFuzzy("efficient", 2); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("efficient within 2 = %v, want [1 2 3]", got)
+	}
+}
+
+func TestEqualScoresOrderedByID(t *testing.T) {
+	docs := map[int]Document{
+		7: {ID: 7, Text: "go fast"},
+		3: {ID: 3, Text: "go slow"},
+		5: {ID: 5, Text: "go"},
+	}
+	for i := 0; i < 20; i++ {
+		if got := (&ByDocSize{}).Rank([]int{7, 5, 3}, docs, nil); !reflect.DeepEqual(got, []int{5, 3, 7}) {
+			t.Fatalf("by size = %v, want [5 3 7]", got)
+		}
+		if got := (&ByKeywordFrequency{}).Rank([]int{7, 5, 3}, docs, []string{"go"}); !reflect.DeepEqual(got, []int{3, 5, 7}) {
+			t.Fatalf("by frequency = %v, want [3 5 7]", got)
+		}
+	}
+}
+
+func TestRecencyRanksNewestFirst(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	engine := newTestEngine(
+		Document{ID: 1, Text: "go", CreatedAt: day(2)},
+		Document{ID: 2, Text: "go"},
+		Document{ID: 3, Text: "go", CreatedAt: day(9)},
+		Document{ID: 4, Text: "go", CreatedAt: day(5)},
+		Document{ID: 5, Text: "go"},
+	)
+	if got := docIDs(engine.SearchQuery(Query{Keyword: "go"}, "recency")); !reflect.DeepEqual(got, []int{3, 4, 1, 2, 5}) {
+		t.Fatalf("recency = %v, want [3 4 1 2 5]", got)
+	}
+}
+
+func TestSaveAndLoadIndex(t *testing.T) {
+	engine := NewSearchEngine(NewBloomInvertedIndexer(100, 0.01), NewCategoryIndexer())
+	engine.AddDocuments(sampleDocs())
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := engine.SaveIndex(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewSearchEngine(NewInvertedIndexer(), NewCategoryIndexer())
+	if err := loaded.LoadIndex(path); err != nil {
+		t.Fatal(err)
+	}
+	queries := []Query{
+		{Keyword: "go"},
+		{Keyword: "is", ExcludedCategories: []string{"concepts"}},
+		{Categories: []string{"programming"}},
+		{Keyword: "rust"},
+	}
+	for _, q := range queries {
+		want := docIDs(engine.SearchQuery(q, "frequency"))
+		if got := docIDs(loaded.SearchQuery(q, "frequency")); !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v: loaded engine found %v, want %v", q, got, want)
+		}
+	}
+	if !reflect.DeepEqual(loaded.Facets("go"), engine.Facets("go")) {
+		t.Errorf("facets differ after loading")
+	}
+
+	if err := loaded.LoadIndex(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("loading a missing file succeeded, want an error")
+	}
+}
+
+func TestRankedSearchLeavesPostingsInPlace(t *testing.T) {
+	indexer := NewInvertedIndexer()
+	engine := NewSearchEngine(indexer, NewCategoryIndexer())
+	engine.AddDocuments([]Document{
+		{ID: 3, Text: "go"},
+		{ID: 1, Text: "go"},
+		{ID: 2, Text: "go go"},
+	})
+
+	if got := docIDs(engine.Search("go", "frequency", NewIndexedCategoryFilter(engine.categoryIndexer, nil))); !reflect.DeepEqual(got, []int{2, 1, 3}) {
+		t.Fatalf("ranked %v, want [2 1 3]", got)
+	}
+	if postings := indexer.index["go"]; !reflect.DeepEqual(postings, []int{3, 1, 2}) {
+		t.Fatalf("postings = %v after a ranked search, want [3 1 2]", postings)
+	}
+}
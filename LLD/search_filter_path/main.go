@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -21,6 +25,24 @@ func (nf NameFilter) Matches(file os.FileInfo) bool {
 	return strings.Contains(file.Name(), nf.Substring)
 }
 
+// RegexFilter - filters files by name regex match
+type RegexFilter struct {
+	Pattern *regexp.Regexp
+}
+
+// NewRegexFilter compiles the pattern, an invalid pattern returns an error
+func NewRegexFilter(pattern string) (RegexFilter, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return RegexFilter{}, fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+	}
+	return RegexFilter{Pattern: re}, nil
+}
+
+func (rf RegexFilter) Matches(file os.FileInfo) bool {
+	return rf.Pattern.MatchString(file.Name())
+}
+
 // SizeFilter - filters files by size constraint (greater than given size)
 type SizeFilter struct {
 	MinSize int64 // in bytes
@@ -44,22 +66,74 @@ func (cf CompositeFilter) Matches(file os.FileInfo) bool {
 	return true
 }
 
+// SortField - the file attribute search results are ordered by
+type SortField string
+
+const (
+	SortNone    SortField = "" // filesystem walk order
+	SortName    SortField = "name"
+	SortSize    SortField = "size"
+	SortModTime SortField = "modtime"
+)
+
 // FileSearcher - handles searching files in a directory based on filters
 type FileSearcher struct {
-	RootDir string
-	Filter  FileFilter
+	RootDir    string
+	Filter     FileFilter
+	SortBy     SortField
+	Descending bool
+}
+
+// match - a matched file with the info captured during the walk
+type match struct {
+	path string
+	info os.FileInfo
+}
+
+// less - orders two matches by the SortBy field, ascending
+func (fs FileSearcher) less(a, b match) bool {
+	switch fs.SortBy {
+	case SortSize:
+		return a.info.Size() < b.info.Size()
+	case SortModTime:
+		return a.info.ModTime().Before(b.info.ModTime())
+	default:
+		return a.info.Name() < b.info.Name()
+	}
+}
+
+// sortMatches - sorts the matches in place, equal files keep their walk order
+func (fs FileSearcher) sortMatches(matches []match) {
+	if fs.SortBy == SortNone {
+		return
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if fs.Descending {
+			return fs.less(matches[j], matches[i])
+		}
+		return fs.less(matches[i], matches[j])
+	})
 }
 
 // Search - walks the directory and finds matching files
 func (fs FileSearcher) Search() ([]string, error) {
-	var matchedFiles []string
+	return fs.SearchContext(context.Background())
+}
+
+// SearchContext - like Search, but stops the walk once ctx is done and
+// returns the context error without any partial results
+func (fs FileSearcher) SearchContext(ctx context.Context) ([]string, error) {
+	var matches []match
 
 	err := filepath.Walk(fs.RootDir, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			return err
 		}
 		if !info.IsDir() && fs.Filter.Matches(info) {
-			matchedFiles = append(matchedFiles, path)
+			matches = append(matches, match{path: path, info: info})
 		}
 		return nil
 	})
@@ -67,6 +141,12 @@ func (fs FileSearcher) Search() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	fs.sortMatches(matches)
+
+	var matchedFiles []string
+	for _, m := range matches {
+		matchedFiles = append(matchedFiles, m.path)
+	}
 	return matchedFiles, nil
 }
 
@@ -96,4 +176,27 @@ func main() {
 	for _, file := range matchingFiles {
 		fmt.Println(file)
 	}
+
+	// A cancelled search stops walking and returns no results
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := searcher.SearchContext(ctx); errors.Is(err, context.Canceled) {
+		fmt.Println("Search cancelled:", err)
+	}
+
+	// Rotated logs, eg. app1.log
+	logFilter, err := NewRegexFilter(`\d\.log$`)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	rotatedLogs, err := FileSearcher{RootDir: dir, Filter: logFilter, SortBy: SortSize, Descending: true}.Search()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("Rotated logs, largest first:")
+	for _, file := range rotatedLogs {
+		fmt.Println(file)
+	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTree writes files of the given sizes under a temporary directory,
+// names may contain a subdirectory
+func newTree(t *testing.T, sizes map[string]int) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, size := range sizes {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// baseNames strips the directories from the paths
+func baseNames(paths []string) []string {
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	return names
+}
+
+func TestRegexFilterMatchesRotatedLogs(t *testing.T) {
+	root := newTree(t, map[string]int{"app1.log": 1, "app.log": 1, "logs/db2.log": 1, "app1.log.gz": 1, "notes9.txt": 1})
+	filter, err := NewRegexFilter(`\d\.log$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FileSearcher{RootDir: root, Filter: filter, SortBy: SortName}.Search()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := baseNames(found); !reflect.DeepEqual(got, []string{"app1.log", "db2.log"}) {
+		t.Fatalf("found %v, want [app1.log db2.log]", got)
+	}
+	if _, err := NewRegexFilter(`[a-`); err == nil {
+		t.Fatal("invalid pattern compiled, want an error")
+	}
+}
+
+func TestSearchSortsResults(t *testing.T) {
+	root := newTree(t, map[string]int{"a.txt": 10, "b.txt": 300, "c.txt": 20, "sub/d.txt": 300})
+	all := NameFilter{Substring: ".txt"}
+
+	found, err := FileSearcher{RootDir: root, Filter: all, SortBy: SortSize, Descending: true}.Search()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// equal sizes keep the walk order
+	if got := baseNames(found); !reflect.DeepEqual(got, []string{"b.txt", "d.txt", "c.txt", "a.txt"}) {
+		t.Fatalf("by size descending got %v", got)
+	}
+
+	// c is the oldest, a the newest
+	now := time.Now()
+	for i, name := range []string{"c.txt", "b.txt", "sub/d.txt", "a.txt"} {
+		modTime := now.Add(time.Duration(i-4) * time.Hour)
+		if err := os.Chtimes(filepath.Join(root, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, err = FileSearcher{RootDir: root, Filter: all, SortBy: SortModTime}.Search()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := baseNames(found); !reflect.DeepEqual(got, []string{"c.txt", "b.txt", "d.txt", "a.txt"}) {
+		t.Fatalf("by modtime got %v", got)
+	}
+}
+
+// cancellingFilter matches every file and cancels the search on the first one
+type cancellingFilter struct {
+	cancel context.CancelFunc
+}
+
+func (cf cancellingFilter) Matches(os.FileInfo) bool {
+	cf.cancel()
+	return true
+}
+
+func TestSearchContextCancelledMidWalk(t *testing.T) {
+	root := newTree(t, map[string]int{"a.txt": 1, "b.txt": 1, "c.txt": 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	found, err := FileSearcher{RootDir: root, Filter: cancellingFilter{cancel}}.SearchContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if found != nil {
+		t.Fatalf("cancelled search returned %v, want no results", found)
+	}
+
+	found, err = FileSearcher{RootDir: root, Filter: NameFilter{}}.SearchContext(context.Background())
+	if err != nil || len(found) != 3 {
+		t.Fatalf("uncancelled search = %v, %v, want all three files", found, err)
+	}
+}
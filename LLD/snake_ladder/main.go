@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -23,6 +25,19 @@ func (d *CrookedDice) Roll() int {
 	return 2 * (rand.Intn(3) + 1)
 }
 
+// SequenceDice rolls the given values in order, starting over after the last.
+// Shared between players it hands out the rolls in turn order.
+type SequenceDice struct {
+	rolls []int
+	next  int
+}
+
+func (d *SequenceDice) Roll() int {
+	roll := d.rolls[d.next%len(d.rolls)]
+	d.next++
+	return roll
+}
+
 // --- Factory Pattern for Board Components ---
 type BoardComponent interface {
 	AffectPosition(int) int
@@ -91,54 +106,244 @@ func (bb *BoardBuilder) Build() *Board {
 type User struct {
 	name     string
 	position int
+	previous int // position before the last move
 	dice     Dice
 }
 
 func (u *User) Move(board *Board) {
 	steps := u.dice.Roll()
 	fmt.Printf("%s rolled a %d\n", u.name, steps)
+	if u.advance(board, steps) {
+		fmt.Printf("%s moved to %d\n", u.name, u.position)
+	}
+}
+
+// advance moves the user by steps applying snakes and ladders,
+// a roll overshooting the board is skipped.
+func (u *User) advance(board *Board, steps int) bool {
 	newPos := u.position + steps
 	if newPos > board.size {
-		return
+		return false
 	}
 
 	for _, component := range board.components {
 		newPos = component.AffectPosition(newPos)
 	}
 
+	u.previous = u.position
 	u.position = newPos
-	fmt.Printf("%s moved to %d\n", u.name, u.position)
+	return true
 }
 
 // --- Game Logic ---
+type GameConfig struct {
+	// KnockBack sends a player back to their previous position when
+	// another player lands exactly on their square
+	KnockBack bool
+	// SimultaneousTurns lets everyone finish the round once a player lands
+	// on the last square, all players there at the end of it win together
+	SimultaneousTurns bool
+	// Dice builds each player's dice, NormalDice when nil
+	Dice func() Dice
+}
+
 type Game struct {
-	users []*User
-	board *Board
+	users  []*User
+	board  *Board
+	turn   int // index of the user to move next
+	config GameConfig
 }
 
 func NewGame(board *Board, users []string) *Game {
+	return NewGameWithConfig(board, users, GameConfig{})
+}
+
+func NewGameWithConfig(board *Board, users []string, config GameConfig) *Game {
 	var u []*User
+	g := &Game{board: board, config: config}
 	for _, name := range users {
-		u = append(u, &User{name: name, position: 0, dice: &NormalDice{}})
+		u = append(u, &User{name: name, position: 0, dice: g.newDice()})
 	}
-	return &Game{users: u, board: board}
+	g.users = u
+	return g
 }
 
+func (g *Game) newDice() Dice {
+	if g.config.Dice != nil {
+		return g.config.Dice()
+	}
+	return &NormalDice{}
+}
+
+// Play runs rounds until somebody wins, several winners are a draw
 func (g *Game) Play() {
 	rand.Seed(time.Now().UnixNano())
-	winner := false
-
-	for !winner {
-		for _, user := range g.users {
-			user.Move(g.board)
+	for {
+		winners := g.PlayRound()
+		if len(winners) == 1 {
+			fmt.Printf("%s wins the game!\n", winners[0])
+			return
+		}
+		if len(winners) > 1 {
+			fmt.Printf("Draw between %s!\n", strings.Join(winners, " and "))
+			return
+		}
+	}
+}
 
-			if user.position == g.board.size {
-				fmt.Printf("%s wins the game!\n", user.name)
-				winner = true
+// PlayRound gives each player one turn, starting with whoever is next, and
+// returns the winners. Players move in turn order and by default the first
+// to land exactly on the last square wins at once, the others don't move.
+// With SimultaneousTurns the round is played out and everyone who finished
+// in it wins.
+func (g *Game) PlayRound() []string {
+	var winners []string
+	for range g.users {
+		user := g.users[g.turn]
+		if g.move() {
+			winners = append(winners, user.name)
+			if !g.config.SimultaneousTurns {
 				break
 			}
 		}
 	}
+	return winners
+}
+
+// PlayTurn moves the user whose turn it is and reports if they won
+func (g *Game) PlayTurn() bool {
+	user := g.users[g.turn]
+	if g.move() {
+		fmt.Printf("%s wins the game!\n", user.name)
+		return true
+	}
+	return false
+}
+
+// move plays the next user's turn and reports if they reached the last square
+func (g *Game) move() bool {
+	user := g.users[g.turn]
+	g.turn = (g.turn + 1) % len(g.users)
+	from := user.position
+	user.Move(g.board)
+	if g.config.KnockBack && user.position != from {
+		g.knockBack(user)
+	}
+	return user.position == g.board.size
+}
+
+// knockBack sends the other users on mover's square back to their previous
+// position, nobody is knocked back from the start or the last square
+func (g *Game) knockBack(mover *User) {
+	if mover.position == 0 || mover.position == g.board.size {
+		return
+	}
+	for _, other := range g.users {
+		if other != mover && other.position == mover.position {
+			other.position = other.previous
+			fmt.Printf("%s knocked %s back to %d\n", mover.name, other.name, other.position)
+		}
+	}
+}
+
+// --- Save / Resume ---
+type PlayerState struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+// GameState is a snapshot of a game in progress
+type GameState struct {
+	Players []PlayerState `json:"players"`
+	Turn    int           `json:"turn"`
+}
+
+func (g *Game) ExportState() GameState {
+	state := GameState{Turn: g.turn}
+	for _, user := range g.users {
+		state.Players = append(state.Players, PlayerState{Name: user.name, Position: user.position})
+	}
+	return state
+}
+
+// LoadState resumes the game from a snapshot, players keep their dice
+// when they are already in the game. A snapshot with a position off the
+// board is rejected and leaves the game as it was.
+func (g *Game) LoadState(state GameState) error {
+	for _, player := range state.Players {
+		if player.Position < 0 || player.Position > g.board.size {
+			return fmt.Errorf("player %s is at %d, off the %d square board", player.Name, player.Position, g.board.size)
+		}
+	}
+
+	dice := make(map[string]Dice)
+	for _, user := range g.users {
+		dice[user.name] = user.dice
+	}
+
+	g.users = nil
+	for _, player := range state.Players {
+		d, ok := dice[player.Name]
+		if !ok {
+			d = g.newDice()
+		}
+		g.users = append(g.users, &User{name: player.Name, position: player.Position, previous: player.Position, dice: d})
+	}
+	g.turn = 0
+	if n := len(g.users); n > 0 {
+		g.turn = (state.Turn%n + n) % n
+	}
+	return nil
+}
+
+// --- Monte Carlo Simulation ---
+// maxSimulationRounds stops a trial that can never finish, eg. a dice
+// that can't roll the exact number needed to land on the last square
+const maxSimulationRounds = 10000
+
+type SimulationStats struct {
+	Trials  int
+	Average float64
+	Min     int
+	Max     int
+}
+
+// Simulate plays trials silent games and reports how many rounds
+// it took for the first player to finish.
+func Simulate(board *Board, players int, trials int, diceFactory func() Dice) SimulationStats {
+	stats := SimulationStats{Trials: trials}
+	total := 0
+	for t := 0; t < trials; t++ {
+		users := make([]*User, players)
+		for i := range users {
+			users[i] = &User{name: fmt.Sprintf("P%d", i+1), dice: diceFactory()}
+		}
+
+		rounds := 0
+		finished := false
+		for !finished && rounds < maxSimulationRounds {
+			rounds++
+			for _, user := range users {
+				user.advance(board, user.dice.Roll())
+				if user.position == board.size {
+					finished = true
+					break
+				}
+			}
+		}
+
+		total += rounds
+		if t == 0 || rounds < stats.Min {
+			stats.Min = rounds
+		}
+		if rounds > stats.Max {
+			stats.Max = rounds
+		}
+	}
+	if trials > 0 {
+		stats.Average = float64(total) / float64(trials)
+	}
+	return stats
 }
 
 // --- Main Execution ---
@@ -148,6 +353,35 @@ func main() {
 		AddComponent(NewBoardComponent("ladder", 3, 22)).
 		Build()
 
-	game := NewGame(board, []string{"Alice", "Bob"})
+	game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{KnockBack: true})
+	for i := 0; i < 3; i++ {
+		game.PlayTurn()
+	}
+	saved, _ := json.Marshal(game.ExportState())
+	fmt.Println("Saved game:", string(saved))
+
+	game.PlayTurn()
+	var state GameState
+	if err := json.Unmarshal(saved, &state); err == nil {
+		if err := game.LoadState(state); err != nil {
+			fmt.Println("Cannot resume:", err)
+		}
+	}
+	fmt.Printf("Resumed game: %+v\n", game.ExportState())
 	game.Play()
+
+	// Everyone rolls a 6 on a 6 square board: in turn order Alice gets there
+	// first, with simultaneous turns Bob finishes the round too and it's a draw
+	short := NewBoardBuilder(6).Build()
+	for _, simultaneous := range []bool{false, true} {
+		sixes := &SequenceDice{rolls: []int{6}}
+		race := NewGameWithConfig(short, []string{"Alice", "Bob"}, GameConfig{
+			SimultaneousTurns: simultaneous,
+			Dice:              func() Dice { return sixes },
+		})
+		race.Play()
+	}
+
+	stats := Simulate(board, 2, 1000, func() Dice { return &NormalDice{} })
+	fmt.Printf("Simulated %d games: avg %.1f rounds (min %d, max %d)\n", stats.Trials, stats.Average, stats.Min, stats.Max)
 }
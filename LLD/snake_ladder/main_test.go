@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// ones rolls a 1 every time so simulated games are deterministic
+func ones() Dice { return &SequenceDice{rolls: []int{1}} }
+
+func TestSimulateLaddersShortenGames(t *testing.T) {
+	bare := NewBoardBuilder(10).Build()
+	laddered := NewBoardBuilder(10).
+		AddComponent(NewBoardComponent("ladder", 1, 9)).
+		Build()
+
+	slow := Simulate(bare, 2, 5, ones)
+	fast := Simulate(laddered, 2, 5, ones)
+	if slow != (SimulationStats{Trials: 5, Average: 10, Min: 10, Max: 10}) {
+		t.Fatalf("bare board stats = %+v, want 10 rounds every trial", slow)
+	}
+	if fast.Average >= slow.Average {
+		t.Fatalf("average with a ladder = %.1f, want fewer than %.1f", fast.Average, slow.Average)
+	}
+	if fast.Min != 2 || fast.Max != 2 {
+		t.Fatalf("ladder board stats = %+v, want 2 rounds every trial", fast)
+	}
+}
+
+func TestSimulateStopsUnfinishableGames(t *testing.T) {
+	// fours land on 4 and 8, then always overshoot 10
+	board := NewBoardBuilder(10).Build()
+	stats := Simulate(board, 1, 1, func() Dice { return &SequenceDice{rolls: []int{4}} })
+	if stats.Max != maxSimulationRounds {
+		t.Fatalf("stats = %+v, want the trial cut off at %d rounds", stats, maxSimulationRounds)
+	}
+}
+
+func TestStateRoundTripsThroughJSON(t *testing.T) {
+	board := NewBoardBuilder(100).Build()
+	game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{
+		Dice: func() Dice { return &SequenceDice{rolls: []int{3, 5}} },
+	})
+	for i := 0; i < 3; i++ {
+		game.PlayTurn()
+	}
+	saved, err := json.Marshal(game.ExportState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game.PlayTurn()
+	game.PlayTurn()
+	var state GameState
+	if err := json.Unmarshal(saved, &state); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.LoadState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	want := GameState{Players: []PlayerState{{"Alice", 8}, {"Bob", 3}}, Turn: 1}
+	if got := game.ExportState(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("resumed state = %+v, want %+v", got, want)
+	}
+	// Bob is next and keeps his dice, which rolled a 3 then a 5 so far
+	game.PlayTurn()
+	if pos := game.ExportState().Players[1].Position; pos != 6 {
+		t.Fatalf("Bob moved to %d after resuming, want 6", pos)
+	}
+}
+
+func TestLoadStateNormalizesNegativeTurn(t *testing.T) {
+	board := NewBoardBuilder(100).Build()
+	game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{
+		Dice: func() Dice { return &SequenceDice{rolls: []int{4}} },
+	})
+	state := GameState{Players: []PlayerState{{"Alice", 10}, {"Bob", 20}}, Turn: -1}
+	if err := game.LoadState(state); err != nil {
+		t.Fatal(err)
+	}
+	if turn := game.ExportState().Turn; turn != 1 {
+		t.Fatalf("turn = %d, want 1", turn)
+	}
+	game.PlayTurn()
+	if got, want := positions(game), []int{10, 24}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("positions = %v, want %v", got, want)
+	}
+}
+
+func TestLoadStateRejectsPositionOffBoard(t *testing.T) {
+	board := NewBoardBuilder(100).Build()
+	game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{})
+	before := game.ExportState()
+	for _, pos := range []int{-1, 101} {
+		state := GameState{Players: []PlayerState{{"Alice", 5}, {"Bob", pos}}}
+		if err := game.LoadState(state); err == nil {
+			t.Fatalf("LoadState accepted Bob at %d", pos)
+		}
+		if got := game.ExportState(); !reflect.DeepEqual(got, before) {
+			t.Fatalf("state after rejected load = %+v, want %+v", got, before)
+		}
+	}
+}
+
+// positions lists where each player stands
+func positions(g *Game) []int {
+	var pos []int
+	for _, player := range g.ExportState().Players {
+		pos = append(pos, player.Position)
+	}
+	return pos
+}
+
+func TestKnockBackSendsPlayerToPreviousSquare(t *testing.T) {
+	board := NewBoardBuilder(100).Build()
+	for _, tc := range []struct {
+		knockBack bool
+		want      []int
+	}{
+		{false, []int{7, 7}},
+		{true, []int{4, 7}},
+	} {
+		// Alice goes 4 then 7, Bob lands on 7 in one roll
+		rolls := &SequenceDice{rolls: []int{4, 1, 3, 6}}
+		game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{
+			KnockBack: tc.knockBack,
+			Dice:      func() Dice { return rolls },
+		})
+		for i := 0; i < 4; i++ {
+			game.PlayTurn()
+		}
+		if got := positions(game); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("knock-back %v: positions = %v, want %v", tc.knockBack, got, tc.want)
+		}
+	}
+}
+
+func TestTurnOrderDecidesWinner(t *testing.T) {
+	board := NewBoardBuilder(6).Build()
+	for _, tc := range []struct {
+		simultaneous bool
+		want         []string
+	}{
+		{false, []string{"Alice"}},
+		{true, []string{"Alice", "Bob"}},
+	} {
+		sixes := &SequenceDice{rolls: []int{6}}
+		game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{
+			SimultaneousTurns: tc.simultaneous,
+			Dice:              func() Dice { return sixes },
+		})
+		if got := game.PlayRound(); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("simultaneous %v: winners = %v, want %v", tc.simultaneous, got, tc.want)
+		}
+	}
+}
+
+func TestRoundStartsWithNextPlayer(t *testing.T) {
+	board := NewBoardBuilder(6).Build()
+	rolls := &SequenceDice{rolls: []int{1, 6}}
+	game := NewGameWithConfig(board, []string{"Alice", "Bob"}, GameConfig{
+		Dice: func() Dice { return rolls },
+	})
+	// Alice's 1 keeps her in the game and Bob moves first next round
+	game.PlayTurn()
+	if got := game.PlayRound(); !reflect.DeepEqual(got, []string{"Bob"}) {
+		t.Fatalf("winners = %v, want [Bob]", got)
+	}
+}
@@ -1,16 +1,36 @@
 package main
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// balanceEpsilon is the rounding slack allowed when amounts must add up
+const balanceEpsilon = 0.01
+
+// Errors returned by the services, wrapped with details so callers can
+// check them with errors.Is
+var (
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrUserNotInGroup = errors.New("user not in group")
+	ErrInvalidSplit   = errors.New("invalid split")
+)
 
 type AddExpenseRequest struct {
-	Title       string
-	Amount      float64
-	PaidBy      string
-	SplitType   SplitType
-	GroupId     string
-	Description string
-	Map         map[string]Amount
-	TotalAmount Amount
+	Title          string
+	Amount         float64
+	PaidBy         string // optional, expenses without a payer are left out of the payment graph
+	SplitType      SplitType
+	GroupId        string
+	Description    string
+	Map            map[string]Amount // per user amount, or percentage for BYPERCENTAGE
+	TotalAmount    Amount
+	Category       string // optional, defaults to DefaultCategory
+	IdempotencyKey string // optional, retries with an already processed key are ignored
 }
 
 type IExpenseService interface {
@@ -21,28 +41,231 @@ type IExpenseService interface {
 type ExpenseService struct {
 	expenseRepo IExpenseRepo
 	groupRepo   IGroupRepo
+	lastID      int
 }
 
 func (service *ExpenseService) AddExpense(expenseRequest *AddExpenseRequest) error {
 	group, err := service.groupRepo.GetGroupById(expenseRequest.GroupId)
-	if group != nil || err != nil {
-		return errors.New("group not found")
+	if err != nil {
+		return fmt.Errorf("get group %s: %w", expenseRequest.GroupId, err)
+	}
+	if group == nil {
+		return fmt.Errorf("%w: %s", ErrGroupNotFound, expenseRequest.GroupId)
+	}
+	if expenseRequest.IdempotencyKey != "" {
+		existing, err := service.expenseRepo.GetExpenseByIdempotencyKey(expenseRequest.IdempotencyKey)
+		if err != nil {
+			return fmt.Errorf("get expense by idempotency key: %w", err)
+		}
+		if existing != nil {
+			// already added by an earlier attempt
+			return nil
+		}
+	}
+	if err := checkMembers(group, expenseRequest); err != nil {
+		return err
 	}
+	balances, err := validateSplits(expenseRequest)
+	if err != nil {
+		return err
+	}
+	category := expenseRequest.Category
+	if category == "" {
+		category = DefaultCategory
+	}
+	service.lastID++
 	expense := &Expense{
-		ID:           "2",
-		Title:        expenseRequest.Title,
-		Description:  expenseRequest.Description,
-		GroupId:      expenseRequest.GroupId,
-		userBalances: expenseRequest.Map,
-		TotalAmount:  expenseRequest.TotalAmount,
-		SplitType:    expenseRequest.SplitType,
+		ID:             strconv.Itoa(service.lastID),
+		Title:          expenseRequest.Title,
+		Description:    expenseRequest.Description,
+		GroupId:        expenseRequest.GroupId,
+		PaidBy:         expenseRequest.PaidBy,
+		userBalances:   balances,
+		TotalAmount:    expenseRequest.TotalAmount,
+		SplitType:      expenseRequest.SplitType,
+		Category:       category,
+		IdempotencyKey: expenseRequest.IdempotencyKey,
 	}
 	if err := service.expenseRepo.AddExpense(expense); err != nil {
-		return err
+		return fmt.Errorf("add expense: %w", err)
+	}
+	return nil
+}
+
+// checkMembers makes sure the payer and everyone in the split belong to the
+// group, groups without a member list accept anyone
+func checkMembers(group *Group, request *AddExpenseRequest) error {
+	if len(group.Members) == 0 {
+		return nil
+	}
+	members := make(map[string]bool, len(group.Members))
+	for _, member := range group.Members {
+		members[member.ID] = true
+	}
+	if request.PaidBy != "" && !members[request.PaidBy] {
+		return fmt.Errorf("%w: %s is not in group %s", ErrUserNotInGroup, request.PaidBy, group.ID)
+	}
+	for user := range request.Map {
+		if !members[user] {
+			return fmt.Errorf("%w: %s is not in group %s", ErrUserNotInGroup, user, group.ID)
+		}
 	}
 	return nil
 }
 
+// validateSplits checks the split adds up, amounts to the total or percentages
+// to 100, and returns each user's amount in a new map. The request is left as is.
+func validateSplits(request *AddExpenseRequest) (map[string]Amount, error) {
+	sum := 0.0
+	for _, amount := range request.Map {
+		if amount.Value < 0 {
+			return nil, fmt.Errorf("%w: negative share %.2f", ErrInvalidSplit, amount.Value)
+		}
+		sum += amount.Value
+	}
+
+	amounts := make(map[string]Amount, len(request.Map))
+	if request.SplitType == BYPERCENTAGE {
+		if math.Abs(sum-100) > balanceEpsilon {
+			return nil, fmt.Errorf("%w: percentages sum to %.2f, expected 100", ErrInvalidSplit, sum)
+		}
+		for user, percent := range request.Map {
+			amounts[user] = Amount{Value: request.TotalAmount.Value * percent.Value / 100}
+		}
+		return amounts, nil
+	}
+
+	if math.Abs(sum-request.TotalAmount.Value) > balanceEpsilon {
+		return nil, fmt.Errorf("%w: shares sum to %.2f, expected %.2f", ErrInvalidSplit, sum, request.TotalAmount.Value)
+	}
+	for user, amount := range request.Map {
+		amounts[user] = amount
+	}
+	return amounts, nil
+}
+
 func (service *ExpenseService) GetPaymentGraph(expense map[string]float64) (map[string]map[string]float64, error) {
+	transactions, err := SimplifyDebts(expense)
+	if err != nil {
+		return nil, err
+	}
+	graph := make(map[string]map[string]float64)
+	for _, t := range transactions {
+		if graph[t.From] == nil {
+			graph[t.From] = make(map[string]float64)
+		}
+		graph[t.From][t.To] += t.Amount
+	}
+	return graph, nil
+}
+
+// maxExactUsers caps the users SimplifyDebts searches exhaustively, larger
+// groups are settled greedily as a whole
+const maxExactUsers = 20
+
+// SimplifyDebts settles the net balances (positive is owed money, negative owes)
+// with the fewest payments possible. The balances have to sum to zero.
+//
+// n users with a balance need n-1 payments, unless they split into smaller
+// groups whose balances each sum to zero and can settle among themselves.
+// The fewest payments come from the split into the most groups, found by
+// looking at every subset of the users, so it's exponential in their number
+// and past maxExactUsers it's only a heuristic. Within a group the largest
+// debtor pays the largest creditor.
+func SimplifyDebts(net map[string]float64) ([]Transaction, error) {
+	var users []string
+	total := 0.0
+	for user, amount := range net {
+		total += amount
+		if math.Abs(amount) > balanceEpsilon {
+			users = append(users, user)
+		}
+	}
+	if math.Abs(total) > balanceEpsilon {
+		return nil, fmt.Errorf("balances sum to %.2f, expected 0", total)
+	}
+	sort.Strings(users)
+	if len(users) > maxExactUsers {
+		return settleGreedily(net, users), nil
+	}
 
+	// for every subset mask of the users, sums is its balance, groups the
+	// most zero sum groups it splits into and last the user added last to
+	// get there
+	full := 1<<len(users) - 1
+	sums := make([]float64, full+1)
+	groups := make([]int, full+1)
+	last := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		sums[mask] = sums[mask&(mask-1)] + net[users[bits.TrailingZeros(uint(mask))]]
+		groups[mask] = -1
+		for i := range users {
+			if rest := mask &^ (1 << i); rest != mask && groups[rest] > groups[mask] {
+				groups[mask], last[mask] = groups[rest], i
+			}
+		}
+		if math.Abs(sums[mask]) <= balanceEpsilon {
+			groups[mask]++
+		}
+	}
+
+	var transactions []Transaction
+	var group []string
+	for mask := full; mask != 0; {
+		i := last[mask]
+		group = append(group, users[i])
+		mask &^= 1 << i
+		if mask == 0 || math.Abs(sums[mask]) <= balanceEpsilon {
+			transactions = append(transactions, settleGreedily(net, group)...)
+			group = nil
+		}
+	}
+	return transactions, nil
+}
+
+// settleGreedily settles the balances of users, which sum to zero, by
+// matching the largest debtor with the largest creditor until all are paid
+func settleGreedily(net map[string]float64, users []string) []Transaction {
+	type balance struct {
+		user   string
+		amount float64
+	}
+	var creditors, debtors []balance
+	for _, user := range users {
+		if amount := net[user]; amount > 0 {
+			creditors = append(creditors, balance{user, amount})
+		} else {
+			debtors = append(debtors, balance{user, -amount})
+		}
+	}
+
+	byAmount := func(b []balance) {
+		sort.Slice(b, func(i, j int) bool {
+			if b[i].amount != b[j].amount {
+				return b[i].amount > b[j].amount
+			}
+			return b[i].user < b[j].user
+		})
+	}
+
+	var transactions []Transaction
+	for len(creditors) > 0 && len(debtors) > 0 {
+		byAmount(creditors)
+		byAmount(debtors)
+		amount := math.Min(creditors[0].amount, debtors[0].amount)
+		transactions = append(transactions, Transaction{
+			From:   debtors[0].user,
+			To:     creditors[0].user,
+			Amount: math.Round(amount*100) / 100,
+		})
+		creditors[0].amount -= amount
+		debtors[0].amount -= amount
+		if creditors[0].amount <= balanceEpsilon {
+			creditors = creditors[1:]
+		}
+		if debtors[0].amount <= balanceEpsilon {
+			debtors = debtors[1:]
+		}
+	}
+	return transactions
 }
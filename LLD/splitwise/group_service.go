@@ -1,9 +1,15 @@
 package main
 
-import "errors"
+import (
+	"fmt"
+	"sort"
+)
 
 type IGroupService interface {
 	GetGroupPaymentGraph(groupId string) (map[string]map[string]float64, error)
+	GetGroupSpendByCategory(groupId string) map[string]float64
+	GetNetGraph(groupId string) ([]Transaction, error)
+	PrintNetGraph(groupId string) error
 }
 
 type GroupService struct {
@@ -14,15 +20,76 @@ type GroupService struct {
 
 func (service *GroupService) GetGroupPaymentGraph(groupId string) (map[string]map[string]float64, error) {
 	group, err := service.groupRepo.GetGroupById(groupId)
-	if group == nil || err != nil {
-		return nil, errors.New("group not found")
+	if err != nil {
+		return nil, fmt.Errorf("get group %s: %w", groupId, err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, groupId)
 	}
 	expenses, err := service.expenseRepo.GetExpenseByGroupId(groupId)
+	if err != nil {
+		return nil, fmt.Errorf("get expenses of group %s: %w", groupId, err)
+	}
+	// the payer is owed the total, everyone else owes their share
 	groupBalance := make(map[string]float64)
 	for _, expense := range expenses {
+		if expense.PaidBy == "" {
+			continue
+		}
+		groupBalance[expense.PaidBy] += expense.TotalAmount.Value
 		for user, amount := range expense.userBalances {
-			groupBalance[user] += amount.Value
+			groupBalance[user] -= amount.Value
 		}
 	}
 	return service.expenseService.GetPaymentGraph(groupBalance)
 }
+
+// GetNetGraph flattens the simplified payment graph into payments, largest
+// first. A settled group has none.
+func (service *GroupService) GetNetGraph(groupId string) ([]Transaction, error) {
+	graph, err := service.GetGroupPaymentGraph(groupId)
+	if err != nil {
+		return nil, err
+	}
+	var payments []Transaction
+	for from, to := range graph {
+		for user, amount := range to {
+			payments = append(payments, Transaction{From: from, To: user, Amount: amount})
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		if payments[i].Amount != payments[j].Amount {
+			return payments[i].Amount > payments[j].Amount
+		}
+		if payments[i].From != payments[j].From {
+			return payments[i].From < payments[j].From
+		}
+		return payments[i].To < payments[j].To
+	})
+	return payments, nil
+}
+
+// PrintNetGraph prints who pays whom, one "A pays B: X" line per payment
+func (service *GroupService) PrintNetGraph(groupId string) error {
+	payments, err := service.GetNetGraph(groupId)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		fmt.Printf("%s pays %s: %.2f\n", payment.From, payment.To, payment.Amount)
+	}
+	return nil
+}
+
+// GetGroupSpendByCategory totals the group's expenses per category
+func (service *GroupService) GetGroupSpendByCategory(groupId string) map[string]float64 {
+	spend := make(map[string]float64)
+	expenses, err := service.expenseRepo.GetExpenseByGroupId(groupId)
+	if err != nil {
+		return spend
+	}
+	for _, expense := range expenses {
+		spend[expense.Category] += expense.TotalAmount.Value
+	}
+	return spend
+}
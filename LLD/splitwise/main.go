@@ -1,15 +1,62 @@
 package main
 
+import (
+	"errors"
+	"fmt"
+)
+
 type SplitWiseService struct {
 	expenseService IExpenseService
 	groupService   IGroupService
 }
 
 func main() {
+	groupRepo := &GroupRepo{groups: make(map[string]*Group)}
+	expenseRepo := &ExpenseRepo{expenses: make(map[string]*Expense)}
+	expenseService := &ExpenseService{expenseRepo: expenseRepo, groupRepo: groupRepo}
 	splitWiseService := &SplitWiseService{
-		expenseService: &ExpenseService{},
-		groupService:   &GroupService{},
+		expenseService: expenseService,
+		groupService:   &GroupService{groupRepo: groupRepo, expenseRepo: expenseRepo, expenseService: expenseService},
+	}
+	groupRepo.AddGroup(&Group{ID: "1", Name: "Trip"})
+
+	splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Lunch", Category: "food", PaidBy: "alice", TotalAmount: Amount{Value: 30},
+		SplitType: UNEQUALLY, Map: map[string]Amount{"alice": {Value: 10}, "bob": {Value: 20}}})
+	splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Dinner", Category: "food", PaidBy: "bob", TotalAmount: Amount{Value: 50},
+		SplitType: BYPERCENTAGE, Map: map[string]Amount{"alice": {Value: 40}, "bob": {Value: 60}}})
+	splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Taxi", Category: "travel", PaidBy: "alice", TotalAmount: Amount{Value: 20},
+		SplitType: EQUALLY, Map: map[string]Amount{"alice": {Value: 10}, "bob": {Value: 10}}})
+	splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Tips", TotalAmount: Amount{Value: 5},
+		SplitType: UNEQUALLY, Map: map[string]Amount{"bob": {Value: 5}}})
+	if err := splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Museum", TotalAmount: Amount{Value: 40},
+		SplitType: UNEQUALLY, Map: map[string]Amount{"alice": {Value: 10}, "bob": {Value: 10}}}); err != nil {
+		fmt.Println(err)
+	}
+	// A retried request with the same key is only added once
+	for attempt := 0; attempt < 2; attempt++ {
+		splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Hotel", Category: "travel", PaidBy: "bob", TotalAmount: Amount{Value: 100},
+			SplitType: EQUALLY, Map: map[string]Amount{"alice": {Value: 50}, "bob": {Value: 50}}, IdempotencyKey: "hotel-night-1"})
+	}
+	splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "1", Title: "Groceries", Category: "food", PaidBy: "carol", TotalAmount: Amount{Value: 60},
+		SplitType: EQUALLY, Map: map[string]Amount{"alice": {Value: 20}, "bob": {Value: 20}, "carol": {Value: 20}}})
+	fmt.Println(splitWiseService.groupService.GetGroupSpendByCategory("1"))
+
+	if err := splitWiseService.groupService.PrintNetGraph("1"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Nothing to print once everyone is settled, and only members can share expenses
+	groupRepo.AddGroup(&Group{ID: "2", Name: "Flat", Members: []*User{{ID: "alice"}, {ID: "bob"}}})
+	err := splitWiseService.expenseService.AddExpense(&AddExpenseRequest{GroupId: "2", Title: "Rent", PaidBy: "alice", TotalAmount: Amount{Value: 900},
+		SplitType: EQUALLY, Map: map[string]Amount{"alice": {Value: 300}, "bob": {Value: 300}, "dave": {Value: 300}}})
+	if errors.Is(err, ErrUserNotInGroup) {
+		fmt.Println(err)
+	}
+	if err := splitWiseService.groupService.PrintNetGraph("2"); err != nil {
+		fmt.Println(err)
+	}
+
+	if _, err := splitWiseService.groupService.GetNetGraph("3"); errors.Is(err, ErrGroupNotFound) {
+		fmt.Println(err)
 	}
-	splitWiseService.expenseService.AddExpense(&AddExpenseRequest{})
-	splitWiseService.groupService.GetGroupPaymentGraph("1")
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math"
 )
 
 // ========================= Models =========================
@@ -36,15 +37,51 @@ type SplitStrategy interface {
 	CalculateSplits(paidBy *User, amount float64, participants []*User) []Split
 }
 
-type EqualSplit struct{}
+// RoundingPolicy decides how EqualSplit handles shares that aren't whole cents
+type RoundingPolicy int
+
+const (
+	RemainderToFirst RoundingPolicy = iota // round to cents, leftover pennies go to the first participants
+	RemainderToLast                        // round to cents, leftover pennies go to the last participants
+	NoRounding                             // exact float shares, may drift by fractions of a cent
+)
+
+type EqualSplit struct {
+	Rounding RoundingPolicy
+}
 
 func (e *EqualSplit) CalculateSplits(paidBy *User, amount float64, participants []*User) []Split {
-	splitAmount := amount / float64(len(participants))
 	splits := []Split{}
-	for _, user := range participants {
+	if len(participants) == 0 {
+		return splits
+	}
+	if e.Rounding == NoRounding {
+		splitAmount := amount / float64(len(participants))
+		for _, user := range participants {
+			splits = append(splits, Split{
+				User:   user,
+				Amount: splitAmount,
+			})
+		}
+		return splits
+	}
+
+	// work in cents so the shares add up to the total exactly
+	total := int64(math.Round(amount * 100))
+	n := int64(len(participants))
+	share, remainder := total/n, total%n
+	for i, user := range participants {
+		cents := share
+		extra := int64(i) < remainder
+		if e.Rounding == RemainderToLast {
+			extra = int64(i) >= n-remainder
+		}
+		if extra {
+			cents++
+		}
 		splits = append(splits, Split{
 			User:   user,
-			Amount: splitAmount,
+			Amount: float64(cents) / 100,
 		})
 	}
 	return splits
@@ -168,6 +205,14 @@ func main() {
 
 	expenseService.AddExpense("u1", 120, []string{"u1", "u2", "u3"}, "Lunch")
 	expenseService.AddExpense("u2", 60, []string{"u2", "u3"}, "Taxi")
+	expenseService.AddExpense("u3", 100, []string{"u1", "u2", "u3"}, "Tickets")
+
+	// 100 among three is 33.34 + 33.33 + 33.33, the extra cent goes to the first participant
+	fmt.Println("Tickets split:")
+	for _, split := range expenseService.expenses[2].Splits {
+		fmt.Printf("%s: %.2f\n", split.User.Name, split.Amount)
+	}
+	fmt.Println()
 
 	fmt.Println("User Expenses for Alice:")
 	for _, exp := range expenseService.GetUserExpenses("u1") {
@@ -0,0 +1,42 @@
+package main
+
+// main2.go is a program of its own, run with: go test main2.go main2_test.go
+
+import "testing"
+
+// cents returns the split amounts in whole cents
+func cents(splits []Split) []int64 {
+	var amounts []int64
+	for _, split := range splits {
+		amounts = append(amounts, int64(split.Amount*100+0.5))
+	}
+	return amounts
+}
+
+func TestEqualSplitSumsToTotal(t *testing.T) {
+	participants := []*User{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	for _, tc := range []struct {
+		rounding RoundingPolicy
+		want     []int64
+	}{
+		{RemainderToFirst, []int64{3334, 3333, 3333}},
+		{RemainderToLast, []int64{3333, 3333, 3334}},
+	} {
+		splits := (&EqualSplit{Rounding: tc.rounding}).CalculateSplits(participants[0], 100, participants)
+		got := cents(splits)
+		sum := int64(0)
+		for i := range got {
+			sum += got[i]
+			if got[i] != tc.want[i] {
+				t.Fatalf("policy %d: splits = %v cents, want %v", tc.rounding, got, tc.want)
+			}
+		}
+		if sum != 10000 {
+			t.Fatalf("policy %d: splits sum to %d cents, want exactly 10000", tc.rounding, sum)
+		}
+	}
+
+	if splits := (&EqualSplit{}).CalculateSplits(nil, 100, nil); len(splits) != 0 {
+		t.Fatalf("no participants: got %v, want no splits", splits)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+// main2.go is a program of its own, run with:
+// go test main.go models.go repo.go expense_service.go group_service.go main_test.go
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// newTestServices returns services over empty repos holding group "1"
+func newTestServices() (*ExpenseService, *GroupService, *ExpenseRepo) {
+	groupRepo := &GroupRepo{groups: make(map[string]*Group)}
+	expenseRepo := &ExpenseRepo{expenses: make(map[string]*Expense)}
+	expenseService := &ExpenseService{expenseRepo: expenseRepo, groupRepo: groupRepo}
+	groupRepo.AddGroup(&Group{ID: "1", Name: "Trip"})
+	return expenseService, &GroupService{groupRepo: groupRepo, expenseRepo: expenseRepo, expenseService: expenseService}, expenseRepo
+}
+
+func TestPercentageSplitLeavesRequestUnchanged(t *testing.T) {
+	expenses, _, repo := newTestServices()
+	percentages := map[string]Amount{"alice": {Value: 40}, "bob": {Value: 60}}
+	request := &AddExpenseRequest{GroupId: "1", Title: "Dinner", PaidBy: "bob", TotalAmount: Amount{Value: 50},
+		SplitType: BYPERCENTAGE, Map: percentages}
+	if err := expenses.AddExpense(request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Map["alice"].Value != 40 || request.Map["bob"].Value != 60 {
+		t.Fatalf("request map = %v, want the percentages kept", request.Map)
+	}
+
+	// retrying the same request still validates as percentages
+	if err := expenses.AddExpense(request); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	stored, _ := repo.GetExpenseById("2")
+	if stored.userBalances["alice"].Value != 20 || stored.userBalances["bob"].Value != 30 {
+		t.Fatalf("stored balances = %v, want alice 20 and bob 30", stored.userBalances)
+	}
+
+	percentages["alice"] = Amount{Value: 100}
+	if stored.userBalances["alice"].Value != 20 {
+		t.Fatal("stored balances share the caller's map")
+	}
+}
+
+func TestInvalidSplitsRejected(t *testing.T) {
+	expenses, _, _ := newTestServices()
+	for _, request := range []*AddExpenseRequest{
+		{GroupId: "1", TotalAmount: Amount{Value: 40}, SplitType: UNEQUALLY, Map: map[string]Amount{"alice": {Value: 10}}},
+		{GroupId: "1", TotalAmount: Amount{Value: 40}, SplitType: BYPERCENTAGE, Map: map[string]Amount{"alice": {Value: 90}}},
+		{GroupId: "1", TotalAmount: Amount{Value: 0}, SplitType: UNEQUALLY, Map: map[string]Amount{"alice": {Value: -5}, "bob": {Value: 5}}},
+	} {
+		if err := expenses.AddExpense(request); !errors.Is(err, ErrInvalidSplit) {
+			t.Errorf("split %v of %.2f: got %v, want ErrInvalidSplit", request.Map, request.TotalAmount.Value, err)
+		}
+	}
+}
+
+// settles reports whether the payments bring every balance in net to zero
+func settles(net map[string]float64, payments []Transaction) bool {
+	left := make(map[string]float64, len(net))
+	for user, amount := range net {
+		left[user] = amount
+	}
+	for _, p := range payments {
+		if p.Amount <= 0 {
+			return false
+		}
+		left[p.From] += p.Amount
+		left[p.To] -= p.Amount
+	}
+	for _, amount := range left {
+		if math.Abs(amount) > balanceEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSimplifyDebtsBeatsPayingEachDebt(t *testing.T) {
+	// a chain of debts, paid one by one it takes three payments
+	debts := []Transaction{
+		{From: "alice", To: "bob", Amount: 10},
+		{From: "bob", To: "carol", Amount: 10},
+		{From: "carol", To: "dave", Amount: 10},
+		{From: "dave", To: "bob", Amount: 5},
+	}
+	net := make(map[string]float64)
+	for _, debt := range debts {
+		net[debt.From] -= debt.Amount
+		net[debt.To] += debt.Amount
+	}
+
+	payments, err := SimplifyDebts(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settles(net, payments) {
+		t.Fatalf("payments %v don't settle %v", payments, net)
+	}
+	if len(payments) != 2 || len(payments) >= len(debts) {
+		t.Fatalf("got %d payments %v, want 2", len(payments), payments)
+	}
+}
+
+func TestSimplifyDebtsFindsMinimum(t *testing.T) {
+	// largest debtor to largest creditor takes 5 payments, settling dave
+	// with carol and the rest among themselves takes 4
+	net := map[string]float64{"alice": 1, "bob": 4, "carol": 5, "dave": -4, "erin": -3, "frank": -3}
+
+	payments, err := SimplifyDebts(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settles(net, payments) {
+		t.Fatalf("payments %v don't settle %v", payments, net)
+	}
+	if len(payments) != 4 {
+		t.Fatalf("got %d payments %v, want 4", len(payments), payments)
+	}
+	if greedy := settleGreedily(net, []string{"alice", "bob", "carol", "dave", "erin", "frank"}); len(greedy) != 5 {
+		t.Fatalf("greedy took %d payments, want 5", len(greedy))
+	}
+}
+
+func TestSimplifyDebtsRejectsUnbalanced(t *testing.T) {
+	if _, err := SimplifyDebts(map[string]float64{"alice": 10, "bob": -5}); err == nil {
+		t.Fatal("want an error for balances summing to 5")
+	}
+	if payments, err := SimplifyDebts(map[string]float64{"alice": 0, "bob": 0.001}); err != nil || len(payments) != 0 {
+		t.Fatalf("settled balances: got %v, %v, want no payments", payments, err)
+	}
+}
+
+// unequal returns a request where payer paid the total split as shares
+func unequal(payer, category string, shares map[string]float64) *AddExpenseRequest {
+	request := &AddExpenseRequest{GroupId: "1", PaidBy: payer, SplitType: UNEQUALLY, Category: category, Map: make(map[string]Amount)}
+	for user, share := range shares {
+		request.Map[user] = Amount{Value: share}
+		request.TotalAmount.Value += share
+	}
+	return request
+}
+
+func TestGroupSpendByCategory(t *testing.T) {
+	expenses, groups, _ := newTestServices()
+	for _, request := range []*AddExpenseRequest{
+		unequal("alice", "food", map[string]float64{"alice": 20, "bob": 20}),
+		unequal("bob", "food", map[string]float64{"alice": 5, "bob": 10}),
+		unequal("alice", "travel", map[string]float64{"alice": 60, "bob": 60}),
+		unequal("bob", "", map[string]float64{"bob": 7}),
+	} {
+		if err := expenses.AddExpense(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]float64{"food": 55, "travel": 120, DefaultCategory: 7}
+	if got := groups.GetGroupSpendByCategory("1"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("spend = %v, want %v", got, want)
+	}
+	if got := groups.GetGroupSpendByCategory("2"); len(got) != 0 {
+		t.Fatalf("spend of an empty group = %v, want none", got)
+	}
+}
+
+func TestRetriedKeyedExpenseAddedOnce(t *testing.T) {
+	expenses, groups, repo := newTestServices()
+	request := unequal("alice", "food", map[string]float64{"alice": 30, "bob": 30})
+	request.IdempotencyKey = "dinner-1"
+	for i := 0; i < 2; i++ {
+		if err := expenses.AddExpense(request); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+
+	if stored, _ := repo.GetExpenseByGroupId("1"); len(stored) != 1 {
+		t.Fatalf("%d expenses stored, want 1", len(stored))
+	}
+	payments, err := groups.GetNetGraph("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Transaction{{From: "bob", To: "alice", Amount: 30}}; !reflect.DeepEqual(payments, want) {
+		t.Fatalf("payments = %v, want %v", payments, want)
+	}
+
+	// requests without a key are never deduplicated
+	unkeyed := unequal("alice", "food", map[string]float64{"bob": 10})
+	expenses.AddExpense(unkeyed)
+	expenses.AddExpense(unkeyed)
+	if stored, _ := repo.GetExpenseByGroupId("1"); len(stored) != 3 {
+		t.Fatalf("%d expenses stored, want 3", len(stored))
+	}
+}
+
+// captureOutput returns what fn prints to stdout
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrintNetGraphLargestFirst(t *testing.T) {
+	expenses, groups, _ := newTestServices()
+	if err := expenses.AddExpense(unequal("alice", "food", map[string]float64{"alice": 30, "bob": 40, "carol": 20})); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureOutput(t, func() {
+		if err := groups.PrintNetGraph("1"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if want := "bob pays alice: 40.00\ncarol pays alice: 20.00\n"; out != want {
+		t.Fatalf("printed %q, want %q", out, want)
+	}
+
+	// carol pays alice back, bob's debt is left
+	expenses.AddExpense(unequal("carol", "", map[string]float64{"alice": 20}))
+	payments, err := groups.GetNetGraph("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Transaction{{From: "bob", To: "alice", Amount: 40}}; !reflect.DeepEqual(payments, want) {
+		t.Fatalf("payments = %v, want %v", payments, want)
+	}
+	expenses.AddExpense(unequal("bob", "", map[string]float64{"alice": 40}))
+	if out := captureOutput(t, func() { groups.PrintNetGraph("1") }); out != "" {
+		t.Fatalf("settled group printed %q, want nothing", out)
+	}
+}
+
+func TestServicesReturnSentinelErrors(t *testing.T) {
+	expenses, groups, _ := newTestServices()
+	if _, err := groups.GetGroupPaymentGraph("missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("payment graph of a missing group: got %v, want ErrGroupNotFound", err)
+	}
+	if _, err := groups.GetNetGraph("missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("net graph of a missing group: got %v, want ErrGroupNotFound", err)
+	}
+	request := unequal("alice", "", map[string]float64{"bob": 10})
+	request.GroupId = "missing"
+	if err := expenses.AddExpense(request); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("expense in a missing group: got %v, want ErrGroupNotFound", err)
+	}
+
+	groups.groupRepo.AddGroup(&Group{ID: "2", Members: []*User{{ID: "alice"}, {ID: "bob"}}})
+	request = unequal("alice", "", map[string]float64{"mallory": 10})
+	request.GroupId = "2"
+	if err := expenses.AddExpense(request); !errors.Is(err, ErrUserNotInGroup) {
+		t.Fatalf("split with an outsider: got %v, want ErrUserNotInGroup", err)
+	}
+}
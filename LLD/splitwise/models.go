@@ -5,16 +5,22 @@ type Amount struct {
 }
 
 type Expense struct {
-	ID           string
-	Title        string
-	ImageUrl     string
-	Description  string
-	GroupId      string
-	userBalances map[string]Amount //user to balance
-	TotalAmount  Amount
-	SplitType    SplitType
+	ID             string
+	Title          string
+	ImageUrl       string
+	Description    string
+	GroupId        string
+	PaidBy         string            // user who paid, empty when not recorded
+	userBalances   map[string]Amount //user to balance
+	TotalAmount    Amount
+	SplitType      SplitType
+	Category       string
+	IdempotencyKey string // of the request that added the expense, if any
 }
 
+// DefaultCategory is used for expenses added without a category
+const DefaultCategory = "other"
+
 type SplitType int
 
 const (
@@ -23,6 +29,13 @@ const (
 	BYPERCENTAGE
 )
 
+// Transaction is a single payment settling part of a debt
+type Transaction struct {
+	From   string
+	To     string
+	Amount float64
+}
+
 type BalanceMap struct {
 	Balances map[string]Amount
 }
@@ -4,6 +4,7 @@ type IExpenseRepo interface {
 	AddExpense(expense *Expense) error
 	GetExpenseByGroupId(groupId string) ([]*Expense, error)
 	GetExpenseById(expenseId string) (*Expense, error)
+	GetExpenseByIdempotencyKey(key string) (*Expense, error)
 }
 
 type ExpenseRepo struct {
@@ -29,6 +30,15 @@ func (repo *ExpenseRepo) GetExpenseById(expenseId string) (*Expense, error) {
 	return repo.expenses[expenseId], nil
 }
 
+func (repo *ExpenseRepo) GetExpenseByIdempotencyKey(key string) (*Expense, error) {
+	for _, expense := range repo.expenses {
+		if expense.IdempotencyKey == key {
+			return expense, nil
+		}
+	}
+	return nil, nil
+}
+
 type IGroupRepo interface {
 	AddGroup(group *Group) error
 	GetGroupById(groupId string) (*Group, error)
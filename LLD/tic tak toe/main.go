@@ -4,6 +4,8 @@ package main
 //Strategy Pattern – To switch between Human vs AI players dynamically.
 import (
 	"fmt"
+	"math/rand"
+	"time"
 )
 
 // Board struct
@@ -41,6 +43,38 @@ func (b *Board) MakeMove(x, y int, mark string) bool {
 	return true
 }
 
+// Reset clears the board for a new game
+func (b *Board) Reset() {
+	b.grid = [3][3]string{}
+}
+
+// ReplayMoves applies logged moves of the form {x, y, symbol} in order,
+// stopping at the first illegal one with an error naming its index. A move
+// is illegal when it is off the board or on a taken cell, when the same
+// symbol moves twice in a row, or when the game has already been won.
+func (b *Board) ReplayMoves(moves [][3]interface{}) error {
+	previous := ""
+	for i, move := range moves {
+		x, okX := move[0].(int)
+		y, okY := move[1].(int)
+		mark, okMark := move[2].(string)
+		if !okX || !okY || !okMark || mark == "" {
+			return fmt.Errorf("move %d: malformed move %v", i, move)
+		}
+		if winner := b.CheckWinner(); winner != "" {
+			return fmt.Errorf("move %d: %s has already won", i, winner)
+		}
+		if mark == previous {
+			return fmt.Errorf("move %d: %s moved twice in a row", i, mark)
+		}
+		previous = mark
+		if !b.MakeMove(x, y, mark) {
+			return fmt.Errorf("move %d: illegal move (%d, %d) for %s", i, x, y, mark)
+		}
+	}
+	return nil
+}
+
 // CheckWinner returns the winner, if any
 func (b *Board) CheckWinner() string {
 	lines := [][][2]int{
@@ -58,6 +92,28 @@ func (b *Board) CheckWinner() string {
 	return ""
 }
 
+// WinCondition decides who has won the game on a board, empty for nobody yet
+type WinCondition interface {
+	Winner(b *Board) string
+}
+
+// StandardWinCondition: three in a row wins
+type StandardWinCondition struct{}
+
+func (w *StandardWinCondition) Winner(b *Board) string {
+	return b.CheckWinner()
+}
+
+// MisereWinCondition: three in a row loses, the other player wins
+type MisereWinCondition struct{}
+
+func (w *MisereWinCondition) Winner(b *Board) string {
+	if loser := b.CheckWinner(); loser != "" {
+		return b.opponentOf(loser)
+	}
+	return ""
+}
+
 // Player interface
 type Player interface {
 	GetMove(*Board) (int, int)
@@ -82,21 +138,129 @@ func (p *HumanPlayer) GetSymbol() string {
 	return p.symbol
 }
 
+// AI difficulty levels accepted by PlayerFactory
+const (
+	AIEasy   = "ai-easy"   // random legal move
+	AIMedium = "ai-medium" // takes immediate wins, blocks immediate losses, else random
+	AIHard   = "ai-hard"   // full minimax, never loses
+)
+
 // AIPlayer struct (Simple AI for demonstration)
 type AIPlayer struct {
-	symbol string
+	symbol     string
+	difficulty string     // empty plays the first available cell
+	rng        *rand.Rand // source for random moves
+}
+
+// NewAIPlayer creates an AI player of the given difficulty using rng for its random moves
+func NewAIPlayer(symbol, difficulty string, rng *rand.Rand) *AIPlayer {
+	return &AIPlayer{symbol: symbol, difficulty: difficulty, rng: rng}
 }
 
-// GetMove returns the first available move
+// GetMove picks a move based on the difficulty
 func (p *AIPlayer) GetMove(b *Board) (int, int) {
+	cells := b.emptyCells()
+	if len(cells) == 0 {
+		return -1, -1
+	}
+	switch p.difficulty {
+	case AIEasy:
+		return p.randomMove(cells)
+	case AIMedium:
+		if x, y, ok := b.winningMove(p.symbol); ok {
+			return x, y
+		}
+		if x, y, ok := b.winningMove(b.opponentOf(p.symbol)); ok {
+			return x, y
+		}
+		return p.randomMove(cells)
+	case AIHard:
+		_, x, y := b.minimax(p.symbol, b.opponentOf(p.symbol), true)
+		return x, y
+	}
+	return cells[0][0], cells[0][1]
+}
+
+func (p *AIPlayer) randomMove(cells [][2]int) (int, int) {
+	cell := cells[p.rng.Intn(len(cells))]
+	return cell[0], cell[1]
+}
+
+// emptyCells returns the free cells in row order
+func (b *Board) emptyCells() [][2]int {
+	var cells [][2]int
 	for i := range b.grid {
 		for j := range b.grid[i] {
 			if b.grid[i][j] == "" {
-				return i, j
+				cells = append(cells, [2]int{i, j})
+			}
+		}
+	}
+	return cells
+}
+
+// winningMove returns a cell that wins the game right away for mark
+func (b *Board) winningMove(mark string) (int, int, bool) {
+	for _, cell := range b.emptyCells() {
+		b.grid[cell[0]][cell[1]] = mark
+		won := b.CheckWinner() == mark
+		b.grid[cell[0]][cell[1]] = ""
+		if won {
+			return cell[0], cell[1], true
+		}
+	}
+	return -1, -1, false
+}
+
+// opponentOf returns the other mark on the board, X and O swap when it's not placed yet
+func (b *Board) opponentOf(mark string) string {
+	for _, row := range b.grid {
+		for _, cell := range row {
+			if cell != "" && cell != mark {
+				return cell
 			}
 		}
 	}
-	return -1, -1
+	if mark == "X" {
+		return "O"
+	}
+	return "X"
+}
+
+// minimax scores the board for me, preferring quicker wins and slower losses,
+// and returns the best move for the player to move
+func (b *Board) minimax(me, opponent string, myTurn bool) (int, int, int) {
+	switch b.CheckWinner() {
+	case me:
+		return 10, -1, -1
+	case opponent:
+		return -10, -1, -1
+	}
+	cells := b.emptyCells()
+	if len(cells) == 0 {
+		return 0, -1, -1
+	}
+
+	bestScore, bestX, bestY := 0, -1, -1
+	mark := opponent
+	if myTurn {
+		mark = me
+	}
+	for _, cell := range cells {
+		b.grid[cell[0]][cell[1]] = mark
+		score, _, _ := b.minimax(me, opponent, !myTurn)
+		b.grid[cell[0]][cell[1]] = ""
+		// shrink scores towards 0 with depth
+		if score > 0 {
+			score--
+		} else if score < 0 {
+			score++
+		}
+		if bestX == -1 || (myTurn && score > bestScore) || (!myTurn && score < bestScore) {
+			bestScore, bestX, bestY = score, cell[0], cell[1]
+		}
+	}
+	return bestScore, bestX, bestY
 }
 
 // GetSymbol returns the AI's symbol
@@ -104,34 +268,48 @@ func (p *AIPlayer) GetSymbol() string {
 	return p.symbol
 }
 
-// PlayerFactory to create players dynamically
+// PlayerFactory to create players dynamically, AI players get a time seeded RNG
 func PlayerFactory(playerType, symbol string) Player {
+	return PlayerFactoryWithRand(playerType, symbol, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// PlayerFactoryWithRand creates players like PlayerFactory with rng behind the AI's random moves
+func PlayerFactoryWithRand(playerType, symbol string, rng *rand.Rand) Player {
 	if playerType == "human" {
 		return &HumanPlayer{symbol: symbol}
 	} else if playerType == "ai" {
 		return &AIPlayer{symbol: symbol}
+	} else if playerType == AIEasy || playerType == AIMedium || playerType == AIHard {
+		return NewAIPlayer(symbol, playerType, rng)
 	}
 	return nil
 }
 
 // Game struct
 type Game struct {
-	board   *Board
-	player1 Player
-	player2 Player
+	board        *Board
+	player1      Player
+	player2      Player
+	winCondition WinCondition
 }
 
-// NewGame initializes the game
+// NewGame initializes the game with the standard rules
 func NewGame(p1, p2 Player) *Game {
+	return NewGameWithWinCondition(p1, p2, &StandardWinCondition{})
+}
+
+// NewGameWithWinCondition initializes the game with alternate rules, eg. misère
+func NewGameWithWinCondition(p1, p2 Player, winCondition WinCondition) *Game {
 	return &Game{
-		board:   NewBoard(),
-		player1: p1,
-		player2: p2,
+		board:        NewBoard(),
+		player1:      p1,
+		player2:      p2,
+		winCondition: winCondition,
 	}
 }
 
-// Play runs the game loop
-func (g *Game) Play() {
+// Play runs the game loop and returns the winner, empty for a draw
+func (g *Game) Play() string {
 	currentPlayer := g.player1
 	for {
 		g.board.Display()
@@ -142,11 +320,16 @@ func (g *Game) Play() {
 			continue
 		}
 
-		winner := g.board.CheckWinner()
+		winner := g.winCondition.Winner(g.board)
 		if winner != "" {
 			g.board.Display()
 			fmt.Printf("Player '%s' wins!\n", winner)
-			break
+			return winner
+		}
+		if len(g.board.emptyCells()) == 0 {
+			g.board.Display()
+			fmt.Println("It's a draw!")
+			return ""
 		}
 
 		// Switch player
@@ -159,9 +342,23 @@ func (g *Game) Play() {
 }
 
 func main() {
+	// Under misère rules X completing a row loses
+	board := NewBoard()
+	if err := board.ReplayMoves([][3]interface{}{{0, 0, "X"}, {1, 0, "O"}, {0, 1, "X"}, {1, 1, "O"}, {0, 2, "X"}}); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("Standard winner: %s, misère winner: %s\n",
+		(&StandardWinCondition{}).Winner(board), (&MisereWinCondition{}).Winner(board))
+
+	// Replaying a corrupt log stops at the bad move
+	board.Reset()
+	if err := board.ReplayMoves([][3]interface{}{{1, 1, "X"}, {3, 0, "O"}}); err != nil {
+		fmt.Println(err)
+	}
+
 	// Creating players
 	player1 := PlayerFactory("human", "X")
-	player2 := PlayerFactory("ai", "O")
+	player2 := PlayerFactory(AIHard, "O")
 
 	// Start the game
 	game := NewGame(player1, player2)
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// threatBoard has X one move from winning the top row
+func threatBoard() *Board {
+	b := NewBoard()
+	if err := b.ReplayMoves([][3]interface{}{{0, 0, "X"}, {1, 1, "O"}, {0, 1, "X"}}); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestMediumAIBlocksThreat(t *testing.T) {
+	ai := PlayerFactoryWithRand(AIMedium, "O", rand.New(rand.NewSource(1)))
+	if x, y := ai.GetMove(threatBoard()); x != 0 || y != 2 {
+		t.Fatalf("medium played (%d, %d), want the block at (0, 2)", x, y)
+	}
+}
+
+func TestEasyAISometimesMissesThreat(t *testing.T) {
+	ai := PlayerFactoryWithRand(AIEasy, "O", rand.New(rand.NewSource(1)))
+	missed := false
+	for i := 0; i < 20 && !missed; i++ {
+		x, y := ai.GetMove(threatBoard())
+		missed = x != 0 || y != 2
+	}
+	if !missed {
+		t.Fatal("easy blocked the threat 20 times in a row")
+	}
+}
+
+func TestSeededAIIsReproducible(t *testing.T) {
+	moves := func() [][2]int {
+		ai := PlayerFactoryWithRand(AIEasy, "O", rand.New(rand.NewSource(42)))
+		var moves [][2]int
+		for i := 0; i < 10; i++ {
+			x, y := ai.GetMove(NewBoard())
+			moves = append(moves, [2]int{x, y})
+		}
+		return moves
+	}
+	first, second := moves(), moves()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("move %d: %v then %v with the same seed", i, first[i], second[i])
+		}
+	}
+}
+
+func TestHardAIGameEndsInDraw(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	game := NewGame(PlayerFactoryWithRand(AIHard, "X", rng), PlayerFactoryWithRand(AIHard, "O", rng))
+	if winner := game.Play(); winner != "" {
+		t.Fatalf("winner = %q, want a draw", winner)
+	}
+	if cells := game.board.emptyCells(); len(cells) != 0 {
+		t.Fatalf("game ended with %d empty cells, want a full board", len(cells))
+	}
+}
+
+// scriptedPlayer plays its moves in order
+type scriptedPlayer struct {
+	symbol string
+	moves  [][2]int
+}
+
+func (p *scriptedPlayer) GetMove(*Board) (int, int) {
+	move := p.moves[0]
+	p.moves = p.moves[1:]
+	return move[0], move[1]
+}
+
+func (p *scriptedPlayer) GetSymbol() string {
+	return p.symbol
+}
+
+func TestMisereLineLoses(t *testing.T) {
+	for _, tc := range []struct {
+		condition WinCondition
+		want      string
+	}{
+		{&StandardWinCondition{}, "X"},
+		{&MisereWinCondition{}, "O"},
+	} {
+		// X completes the top row on its third move
+		x := &scriptedPlayer{symbol: "X", moves: [][2]int{{0, 0}, {0, 1}, {0, 2}}}
+		o := &scriptedPlayer{symbol: "O", moves: [][2]int{{1, 0}, {2, 2}}}
+		game := NewGameWithWinCondition(x, o, tc.condition)
+		if winner := game.Play(); winner != tc.want {
+			t.Fatalf("%T: winner = %q, want %q", tc.condition, winner, tc.want)
+		}
+	}
+}
+
+func TestReplayValidGame(t *testing.T) {
+	b := NewBoard()
+	moves := [][3]interface{}{{1, 1, "X"}, {0, 0, "O"}, {0, 2, "X"}, {2, 2, "O"}, {2, 0, "X"}}
+	if err := b.ReplayMoves(moves); err != nil {
+		t.Fatal(err)
+	}
+	if winner := b.CheckWinner(); winner != "X" {
+		t.Fatalf("winner = %q, want X on the diagonal", winner)
+	}
+
+	b.Reset()
+	if cells := b.emptyCells(); len(cells) != 9 {
+		t.Fatalf("%d empty cells after Reset, want 9", len(cells))
+	}
+	if err := b.ReplayMoves(moves[:2]); err != nil {
+		t.Fatalf("replaying on a reset board: %v", err)
+	}
+}
+
+func TestReplayStopsAtIllegalMove(t *testing.T) {
+	for _, tc := range []struct {
+		moves [][3]interface{}
+		want  string
+	}{
+		{[][3]interface{}{{0, 0, "X"}, {1, 1, "O"}, {3, 0, "X"}, {2, 2, "O"}}, "move 2:"},
+		{[][3]interface{}{{0, 0, "X"}, {0, 0, "O"}}, "move 1:"},
+		{[][3]interface{}{{"0", 0, "X"}}, "move 0:"},
+	} {
+		b := NewBoard()
+		err := b.ReplayMoves(tc.moves)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Fatalf("replaying %v: got %v, want an error starting with %q", tc.moves, err, tc.want)
+		}
+	}
+
+	// the moves before the illegal one stay on the board
+	b := NewBoard()
+	b.ReplayMoves([][3]interface{}{{0, 0, "X"}, {-1, 0, "O"}})
+	if len(b.emptyCells()) != 8 {
+		t.Fatalf("board = %v, want only the first move applied", b.grid)
+	}
+}
+
+func TestReplayRejectsSameSymbolTwice(t *testing.T) {
+	b := NewBoard()
+	err := b.ReplayMoves([][3]interface{}{{0, 0, "X"}, {1, 1, "O"}, {2, 2, "O"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "move 2:") {
+		t.Fatalf("got %v, want an error for move 2", err)
+	}
+	if len(b.emptyCells()) != 7 {
+		t.Fatalf("board = %v, want only the first two moves applied", b.grid)
+	}
+}
+
+func TestReplayRejectsMoveAfterWin(t *testing.T) {
+	b := NewBoard()
+	moves := [][3]interface{}{{0, 0, "X"}, {1, 0, "O"}, {0, 1, "X"}, {1, 1, "O"}, {0, 2, "X"}, {2, 2, "O"}}
+	err := b.ReplayMoves(moves)
+	if err == nil || !strings.HasPrefix(err.Error(), "move 5:") {
+		t.Fatalf("got %v, want an error for move 5", err)
+	}
+	if b.grid[2][2] != "" {
+		t.Fatalf("move after the win was applied: %v", b.grid)
+	}
+}
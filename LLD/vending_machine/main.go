@@ -3,34 +3,100 @@ package main
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
 // used payment strategy pattern
 // used state pattern for vending machine states
 // used mutex for thread safety
 
+// Errors returned by the vending machine, wrapped with details so callers
+// can check them with errors.Is
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrOutOfStock        = errors.New("product out of stock")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrNoPaymentMethod   = errors.New("no payment method selected")
+	ErrWrongState        = errors.New("not allowed in the current state")
+	ErrProductExpired    = errors.New("product expired")
+	ErrInvalidPromo      = errors.New("invalid or expired promo code")
+	ErrPromoApplied      = errors.New("promo code already applied")
+	ErrInvalidPickupCode = errors.New("invalid or expired reservation code")
+)
+
 // Product represents a product in the vending machine
 type Product struct {
-	Name     string
-	Price    int
-	Quantity int
+	Name      string
+	Price     int
+	Quantity  int
+	ExpiresAt time.Time // zero value means the product never expires
+}
+
+// IsExpired checks if the product is past its expiry date
+func (p *Product) IsExpired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && now.After(p.ExpiresAt)
+}
+
+// PromoCode gives a percentage discount on a product within its validity window
+type PromoCode struct {
+	Code       string
+	Percent    int
+	ValidFrom  time.Time
+	ValidUntil time.Time
+}
+
+// IsValid checks if the promo code can be used at the given time
+func (p *PromoCode) IsValid(now time.Time) bool {
+	return p.Percent > 0 && p.Percent <= 100 && !now.Before(p.ValidFrom) && !now.After(p.ValidUntil)
 }
 
+// Apply returns the price after the promo discount
+func (p *PromoCode) Apply(price int) int {
+	return price - price*p.Percent/100
+}
+
+// Receipt holds the details of a completed purchase
+type Receipt struct {
+	ProductName    string
+	Price          int
+	AmountTendered int
+	Change         int
+	Timestamp      time.Time
+}
+
+// Reservation holds a product aside for pickup with a code until it expires
+type Reservation struct {
+	Code        string
+	ProductName string
+	ExpiresAt   time.Time
+}
+
+// defaultReservationTTL is used when the machine has no ReservationTTL set
+const defaultReservationTTL = 15 * time.Minute
+
 // VendingMachine represents the vending machine
 type VendingMachine struct {
-	Products      map[string]*Product // product name -> quantity
-	Balance       int                 // current balance in the machine
-	State         VendingMachineState
-	PaymentMethod PaymentStrategy
-	mu            sync.Mutex
+	Products        map[string]*Product     // product name -> quantity
+	PromoCodes      map[string]*PromoCode   // promo code -> promo
+	Reservations    map[string]*Reservation // pickup code -> reservation
+	ReservationTTL  time.Duration
+	Balance         int // current balance in the machine
+	ChangeReturned  int // total change handed back to customers
+	State           VendingMachineState
+	PaymentMethod   PaymentStrategy
+	DispenseHook    func(productName string) // optional, called before a product is dispensed
+	DispenseDelay   time.Duration            // optional pause after the hook, eg. a motor animation
+	lastReservation int
+	mu              sync.Mutex
 }
 
 // VendingMachineState defines the interface for vending machine states
 type VendingMachineState interface {
 	SelectProduct(vm *VendingMachine, productName string) error
 	InsertMoney(vm *VendingMachine, amount int) error
-	DispenseProduct(vm *VendingMachine) error
+	DispenseProduct(vm *VendingMachine) (*Receipt, error)
 }
 
 // IdleState represents the idle state of the vending machine
@@ -38,82 +104,148 @@ type IdleState struct{}
 
 func (i *IdleState) SelectProduct(vm *VendingMachine, productName string) error {
 	if _, exists := vm.Products[productName]; !exists {
-		return errors.New("product not found")
+		return fmt.Errorf("%w: %s", ErrProductNotFound, productName)
+	}
+	if vm.Products[productName].IsExpired(time.Now()) {
+		return fmt.Errorf("%w: %s", ErrProductExpired, productName)
 	}
 	if vm.Products[productName].Quantity <= 0 {
-		return errors.New("product out of stock")
+		return fmt.Errorf("%w: %s", ErrOutOfStock, productName)
 	}
-	vm.State = &ProcessingState{SelectedProduct: productName}
+	vm.State = &ProcessingState{SelectedProduct: productName, Price: vm.Products[productName].Price}
 	return nil
 }
 
 func (i *IdleState) InsertMoney(vm *VendingMachine, amount int) error {
-	return errors.New("please select a product first")
+	return fmt.Errorf("%w: please select a product first", ErrWrongState)
 }
 
-func (i *IdleState) DispenseProduct(vm *VendingMachine) error {
-	return errors.New("please select a product first")
+func (i *IdleState) DispenseProduct(vm *VendingMachine) (*Receipt, error) {
+	return nil, fmt.Errorf("%w: please select a product first", ErrWrongState)
 }
 
 // ProcessingState represents the state when a product is selected
 type ProcessingState struct {
 	SelectedProduct string
+	Price           int    // effective price after any promo
+	Promo           string // applied promo code, if any
 }
 
 func (p *ProcessingState) SelectProduct(vm *VendingMachine, productName string) error {
-	return errors.New("already processing a product")
+	return fmt.Errorf("%w: already processing a product", ErrWrongState)
 }
 
 func (p *ProcessingState) InsertMoney(vm *VendingMachine, amount int) error {
 	if vm.PaymentMethod == nil {
-		return errors.New("no payment method selected")
+		return ErrNoPaymentMethod
 	}
 	err := vm.PaymentMethod.Pay(amount)
 	if err != nil {
 		return err
 	}
 	vm.Balance += amount
-	if vm.Balance >= vm.Products[p.SelectedProduct].Price {
-		vm.State = &DispensingState{}
+	if vm.Balance >= p.Price {
+		vm.State = &DispensingState{SelectedProduct: p.SelectedProduct, Price: p.Price}
 	}
 	return nil
 }
 
-func (p *ProcessingState) DispenseProduct(vm *VendingMachine) error {
-	return errors.New("please insert more money")
+// ApplyPromo reduces the effective price of the selected product
+func (p *ProcessingState) ApplyPromo(vm *VendingMachine, code string) error {
+	if p.Promo != "" {
+		return fmt.Errorf("%w: %s", ErrPromoApplied, p.Promo)
+	}
+	promo, exists := vm.PromoCodes[code]
+	if !exists || !promo.IsValid(time.Now()) {
+		return fmt.Errorf("%w: %s", ErrInvalidPromo, code)
+	}
+	p.Price = promo.Apply(p.Price)
+	p.Promo = code
+	if vm.Balance >= p.Price {
+		vm.State = &DispensingState{SelectedProduct: p.SelectedProduct, Price: p.Price}
+	}
+	return nil
+}
+
+func (p *ProcessingState) DispenseProduct(vm *VendingMachine) (*Receipt, error) {
+	return nil, fmt.Errorf("%w: please insert %d more", ErrInsufficientFunds, p.Price-vm.Balance)
 }
 
 // DispensingState represents the state when the product is being dispensed
-type DispensingState struct{}
+type DispensingState struct {
+	SelectedProduct string
+	Price           int
+	inProgress      bool // the dispense hook is running
+}
 
 func (d *DispensingState) SelectProduct(vm *VendingMachine, productName string) error {
-	return errors.New("currently dispensing a product")
+	return fmt.Errorf("%w: currently dispensing a product", ErrWrongState)
 }
 
 func (d *DispensingState) InsertMoney(vm *VendingMachine, amount int) error {
-	return errors.New("currently dispensing a product")
+	return fmt.Errorf("%w: currently dispensing a product", ErrWrongState)
 }
 
-func (d *DispensingState) DispenseProduct(vm *VendingMachine) error {
-	vm.mu.Lock()
-	defer vm.mu.Unlock()
-
-	productPrice := vm.Products[vm.State.(*ProcessingState).SelectedProduct].Price
+func (d *DispensingState) DispenseProduct(vm *VendingMachine) (*Receipt, error) {
+	productPrice := d.Price
 	if vm.Balance < productPrice {
-		return errors.New("insufficient funds")
+		return nil, fmt.Errorf("%w: please insert %d more", ErrInsufficientFunds, productPrice-vm.Balance)
+	}
+
+	// the stock may have changed while the dispense hook ran without the lock,
+	// eg. the product purged as expired or its last unit reserved
+	product, exists := vm.Products[d.SelectedProduct]
+	if !exists || product.Quantity <= 0 {
+		refund := vm.Balance
+		fmt.Printf("Returning change: %d\n", refund)
+		vm.ChangeReturned += refund
+		vm.Balance = 0
+		vm.State = &IdleState{}
+		if !exists {
+			return nil, fmt.Errorf("%w: %s, refunded %d", ErrProductNotFound, d.SelectedProduct, refund)
+		}
+		return nil, fmt.Errorf("%w: %s, refunded %d", ErrOutOfStock, d.SelectedProduct, refund)
 	}
 
-	vm.Products[vm.State.(*ProcessingState).SelectedProduct].Quantity--
+	receipt := &Receipt{
+		ProductName:    d.SelectedProduct,
+		Price:          productPrice,
+		AmountTendered: vm.Balance,
+		Timestamp:      time.Now(),
+	}
+
+	product.Quantity--
 	vm.Balance -= productPrice
-	fmt.Printf("Dispensing %s\n", vm.State.(*ProcessingState).SelectedProduct)
+	fmt.Printf("Dispensing %s\n", d.SelectedProduct)
 
 	if vm.Balance > 0 {
 		fmt.Printf("Returning change: %d\n", vm.Balance)
+		receipt.Change = vm.Balance
+		vm.ChangeReturned += vm.Balance
 		vm.Balance = 0
 	}
 
 	vm.State = &IdleState{}
-	return nil
+	return receipt, nil
+}
+
+// stateName returns the short name of a state used in transition events
+func stateName(state VendingMachineState) string {
+	switch state.(type) {
+	case *IdleState:
+		return "Idle"
+	case *ProcessingState:
+		return "Processing"
+	case *DispensingState:
+		return "Dispensing"
+	}
+	return fmt.Sprintf("%T", state)
+}
+
+// StateObserver is notified of every vending machine state change. It's called
+// with the machine lock held, so it must not call back into the service.
+type StateObserver interface {
+	OnTransition(from, to string)
 }
 
 // PaymentStrategy defines the interface for payment methods
@@ -145,9 +277,14 @@ func (c *CardPayment) Pay(amount int) error {
 	return nil
 }
 
-// VendingMachineService implements the business logic for the vending machine
+// VendingMachineService implements the business logic for the vending machine.
+// Every call takes the machine lock, so only one transaction is open at a time:
+// a select made while another product is being bought fails with ErrWrongState.
+// Calls aren't tied to a caller though, the pay and dispense steps of the open
+// transaction are accepted from anyone.
 type VendingMachineService struct {
-	vm *VendingMachine
+	vm        *VendingMachine
+	observers []StateObserver
 }
 
 // NewVendingMachineService creates a new service
@@ -155,22 +292,93 @@ func NewVendingMachineService(vm *VendingMachine) *VendingMachineService {
 	return &VendingMachineService{vm: vm}
 }
 
+// AddObserver registers an observer for the machine state changes
+func (s *VendingMachineService) AddObserver(observer StateObserver) {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// notifyTransition tells the observers if the state changed from the given one,
+// it expects the machine lock to be held
+func (s *VendingMachineService) notifyTransition(from string) {
+	to := stateName(s.vm.State)
+	if from == to {
+		return
+	}
+	for _, observer := range s.observers {
+		observer.OnTransition(from, to)
+	}
+}
+
 // SelectProduct selects a product
 func (s *VendingMachineService) SelectProduct(productName string) error {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	defer s.notifyTransition(stateName(s.vm.State))
 	return s.vm.State.SelectProduct(s.vm, productName)
 }
 
 // InsertMoney inserts money into the vending machine using the selected payment method
 func (s *VendingMachineService) InsertMoney(amount int, paymentMethod PaymentStrategy) error {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	defer s.notifyTransition(stateName(s.vm.State))
 	s.vm.PaymentMethod = paymentMethod
 	return s.vm.State.InsertMoney(s.vm, amount)
 }
 
-// DispenseProduct dispenses the selected product
-func (s *VendingMachineService) DispenseProduct() error {
+// DispenseProduct dispenses the selected product and returns the purchase receipt.
+// The dispense hook and delay run before the stock is taken, without the machine
+// lock so a slow front-end doesn't block the other calls. The stock is checked
+// again once the lock is back, a product gone meanwhile is refunded.
+func (s *VendingMachineService) DispenseProduct() (*Receipt, error) {
+	s.vm.mu.Lock()
+	if state, ok := s.vm.State.(*DispensingState); ok && s.vm.Balance >= state.Price {
+		if state.inProgress {
+			s.vm.mu.Unlock()
+			return nil, fmt.Errorf("%w: currently dispensing a product", ErrWrongState)
+		}
+		hook, delay := s.vm.DispenseHook, s.vm.DispenseDelay
+		state.inProgress = true
+		s.vm.mu.Unlock()
+
+		if hook != nil {
+			hook(state.SelectedProduct)
+		}
+		time.Sleep(delay)
+
+		s.vm.mu.Lock()
+		state.inProgress = false
+	}
+	defer s.vm.mu.Unlock()
+	defer s.notifyTransition(stateName(s.vm.State))
 	return s.vm.State.DispenseProduct(s.vm)
 }
 
+// ApplyPromo applies a promo code to the currently selected product
+func (s *VendingMachineService) ApplyPromo(code string) error {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	defer s.notifyTransition(stateName(s.vm.State))
+	state, ok := s.vm.State.(*ProcessingState)
+	if !ok {
+		return fmt.Errorf("%w: please select a product first", ErrWrongState)
+	}
+	return state.ApplyPromo(s.vm, code)
+}
+
+// AmountDue returns the money still owed for the selected product
+func (s *VendingMachineService) AmountDue() int {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	state, ok := s.vm.State.(*ProcessingState)
+	if !ok {
+		return 0
+	}
+	return state.Price - s.vm.Balance
+}
+
 // Restock adds more products to the vending machine
 func (s *VendingMachineService) Restock(productName string, quantity int) {
 	s.vm.mu.Lock()
@@ -178,6 +386,122 @@ func (s *VendingMachineService) Restock(productName string, quantity int) {
 	s.vm.Products[productName].Quantity += quantity
 }
 
+// RemoveExpired purges the expired products from the vending machine
+// and returns the names of the removed products
+func (s *VendingMachineService) RemoveExpired() []string {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	now := time.Now()
+	var removed []string
+	for name, product := range s.vm.Products {
+		if product.IsExpired(now) {
+			delete(s.vm.Products, name)
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+// Reserve takes one unit of the product out of stock and returns
+// the pickup code to redeem it with before the reservation expires
+func (s *VendingMachineService) Reserve(productName string) (string, error) {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	s.expireReservations(time.Now())
+
+	product, exists := s.vm.Products[productName]
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrProductNotFound, productName)
+	}
+	if product.IsExpired(time.Now()) {
+		return "", fmt.Errorf("%w: %s", ErrProductExpired, productName)
+	}
+	if product.Quantity <= 0 {
+		return "", fmt.Errorf("%w: %s", ErrOutOfStock, productName)
+	}
+
+	ttl := s.vm.ReservationTTL
+	if ttl <= 0 {
+		ttl = defaultReservationTTL
+	}
+	if s.vm.Reservations == nil {
+		s.vm.Reservations = make(map[string]*Reservation)
+	}
+	s.vm.lastReservation++
+	code := fmt.Sprintf("R%04d", s.vm.lastReservation)
+	s.vm.Reservations[code] = &Reservation{Code: code, ProductName: productName, ExpiresAt: time.Now().Add(ttl)}
+	product.Quantity--
+	return code, nil
+}
+
+// Redeem dispenses the product reserved under the pickup code
+func (s *VendingMachineService) Redeem(code string) error {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	s.expireReservations(time.Now())
+
+	reservation, exists := s.vm.Reservations[code]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrInvalidPickupCode, code)
+	}
+	delete(s.vm.Reservations, code)
+	fmt.Printf("Dispensing reserved %s\n", reservation.ProductName)
+	return nil
+}
+
+// ExpireReservations puts the stock of the expired reservations back
+// and returns their codes
+func (s *VendingMachineService) ExpireReservations() []string {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	return s.expireReservations(time.Now())
+}
+
+// expireReservations expects the machine lock to be held
+func (s *VendingMachineService) expireReservations(now time.Time) []string {
+	var expired []string
+	for code, reservation := range s.vm.Reservations {
+		if now.Before(reservation.ExpiresAt) {
+			continue
+		}
+		if product, exists := s.vm.Products[reservation.ProductName]; exists {
+			product.Quantity++
+		}
+		delete(s.vm.Reservations, code)
+		expired = append(expired, code)
+	}
+	sort.Strings(expired)
+	return expired
+}
+
+// Diagnostics is a health snapshot of the vending machine for operators
+type Diagnostics struct {
+	TotalProducts  int
+	TotalQuantity  int
+	OutOfStock     []string
+	Balance        int
+	ChangeReturned int
+}
+
+// Diagnostics returns the current stock and money totals of the machine
+func (s *VendingMachineService) Diagnostics() Diagnostics {
+	s.vm.mu.Lock()
+	defer s.vm.mu.Unlock()
+	report := Diagnostics{
+		TotalProducts:  len(s.vm.Products),
+		Balance:        s.vm.Balance,
+		ChangeReturned: s.vm.ChangeReturned,
+	}
+	for name, product := range s.vm.Products {
+		report.TotalQuantity += product.Quantity
+		if product.Quantity <= 0 {
+			report.OutOfStock = append(report.OutOfStock, name)
+		}
+	}
+	sort.Strings(report.OutOfStock)
+	return report
+}
+
 // CollectMoney retrieves the money from the vending machine
 func (s *VendingMachineService) CollectMoney() int {
 	s.vm.mu.Lock()
@@ -187,19 +511,33 @@ func (s *VendingMachineService) CollectMoney() int {
 	return money
 }
 
+// transitionLog prints every state change
+type transitionLog struct{}
+
+func (l *transitionLog) OnTransition(from, to string) {
+	fmt.Printf("State: %s -> %s\n", from, to)
+}
+
 func main() {
 	// Initialize vending machine
 	vm := &VendingMachine{
-		Products: make(map[string]*Product),
-		Balance:  0,
-		State:    &IdleState{},
+		Products:   make(map[string]*Product),
+		PromoCodes: make(map[string]*PromoCode),
+		Balance:    0,
+		State:      &IdleState{},
 	}
+	vm.PromoCodes["SAVE20"] = &PromoCode{Code: "SAVE20", Percent: 20, ValidFrom: time.Now(), ValidUntil: time.Now().Add(24 * time.Hour)}
 
 	// Add products
 	vm.Products["Coke"] = &Product{Name: "Coke", Price: 10, Quantity: 10}
 	vm.Products["Pepsi"] = &Product{Name: "Pepsi", Price: 15, Quantity: 10}
+	vm.Products["Water"] = &Product{Name: "Water", Price: 5, Quantity: 0}
+	vm.Products["Milk"] = &Product{Name: "Milk", Price: 20, Quantity: 5, ExpiresAt: time.Now().Add(-time.Hour)}
 	// Initialize service
 	vmService := NewVendingMachineService(vm)
+	vmService.AddObserver(&transitionLog{})
+	vm.DispenseHook = func(productName string) { fmt.Printf("Preparing %s...\n", productName) }
+	vm.DispenseDelay = 10 * time.Millisecond
 
 	// Simulate a transaction
 	err := vmService.SelectProduct("Coke")
@@ -215,15 +553,62 @@ func main() {
 		return
 	}
 
-	err = vmService.DispenseProduct()
+	receipt, err := vmService.DispenseProduct()
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+	fmt.Printf("Receipt: %s, price %d, paid %d, change %d\n",
+		receipt.ProductName, receipt.Price, receipt.AmountTendered, receipt.Change)
+
+	// Buy a Pepsi with a promo code
+	if err := vmService.SelectProduct("Pepsi"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := vmService.ApplyPromo("SAVE20"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Amount due after promo: %d\n", vmService.AmountDue())
+	if err := vmService.InsertMoney(vmService.AmountDue(), &NotePayment{}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := vmService.DispenseProduct(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Sold out products can be told apart from other failures
+	if err := vmService.SelectProduct("Water"); errors.Is(err, ErrOutOfStock) {
+		fmt.Println("Sold out:", err)
+	}
 
 	// Restock products
 	vmService.Restock("Coke", 10)
 
+	// Expired products can't be selected and are purged on maintenance
+	if err := vmService.SelectProduct("Milk"); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("Removed expired products: %v\n", vmService.RemoveExpired())
+
+	// Reserve a Coke for pickup, the second reservation is never redeemed
+	vm.ReservationTTL = 50 * time.Millisecond
+	if code, err := vmService.Reserve("Coke"); err == nil {
+		if err := vmService.Redeem(code); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if _, err := vmService.Reserve("Coke"); err != nil {
+		fmt.Println(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	fmt.Printf("Expired reservations: %v, Coke stock: %d\n", vmService.ExpireReservations(), vm.Products["Coke"].Quantity)
+
+	fmt.Printf("Diagnostics: %+v\n", vmService.Diagnostics())
+
 	// Collect money
 	money := vmService.CollectMoney()
 	fmt.Printf("Collected money: %d\n", money)
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestService returns a service over an idle machine stocked with a Coke for 10
+func newTestService() (*VendingMachineService, *VendingMachine) {
+	vm := &VendingMachine{
+		Products:   map[string]*Product{"Coke": {Name: "Coke", Price: 10, Quantity: 5}},
+		PromoCodes: make(map[string]*PromoCode),
+		State:      &IdleState{},
+	}
+	return NewVendingMachineService(vm), vm
+}
+
+func TestDispenseHookReceivesProductName(t *testing.T) {
+	s, vm := newTestService()
+	var hooked []string
+	vm.DispenseHook = func(productName string) {
+		// the stock isn't taken until the hook returns
+		if got := s.Diagnostics().TotalQuantity; got != 5 {
+			t.Errorf("stock during hook = %d, want 5", got)
+		}
+		hooked = append(hooked, productName)
+	}
+
+	if err := s.SelectProduct("Coke"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMoney(10, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DispenseProduct(); err != nil {
+		t.Fatal(err)
+	}
+	if len(hooked) != 1 || hooked[0] != "Coke" {
+		t.Fatalf("hook got %v, want [Coke]", hooked)
+	}
+	if vm.Products["Coke"].Quantity != 4 {
+		t.Fatalf("stock after dispense = %d, want 4", vm.Products["Coke"].Quantity)
+	}
+}
+
+func TestDispenseRefundsProductRemovedDuringHook(t *testing.T) {
+	s, vm := newTestService()
+	vm.Products["Coke"].ExpiresAt = time.Now().Add(20 * time.Millisecond)
+	vm.DispenseHook = func(string) {
+		time.Sleep(40 * time.Millisecond)
+		s.RemoveExpired()
+	}
+
+	if err := s.SelectProduct("Coke"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMoney(15, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := s.DispenseProduct()
+	if !errors.Is(err, ErrProductNotFound) || receipt != nil {
+		t.Fatalf("got %v, %v, want ErrProductNotFound", receipt, err)
+	}
+	if report := s.Diagnostics(); report.Balance != 0 || report.ChangeReturned != 15 {
+		t.Fatalf("got balance %d, change returned %d, want the 15 refunded", report.Balance, report.ChangeReturned)
+	}
+	if _, idle := vm.State.(*IdleState); !idle {
+		t.Fatalf("state = %s, want Idle", stateName(vm.State))
+	}
+}
+
+// run with -race
+func TestConcurrentSelectsStartOneTransaction(t *testing.T) {
+	s, vm := newTestService()
+	vm.Products["Pepsi"] = &Product{Name: "Pepsi", Price: 15, Quantity: 5}
+
+	products := []string{"Coke", "Pepsi"}
+	results := make(chan error, 20)
+	var start sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			start.Wait()
+			results <- s.SelectProduct(products[i%2])
+		}()
+	}
+	start.Done()
+
+	selected := 0
+	for i := 0; i < cap(results); i++ {
+		err := <-results
+		switch {
+		case err == nil:
+			selected++
+		case !errors.Is(err, ErrWrongState):
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if selected != 1 {
+		t.Fatalf("%d selects succeeded, want 1", selected)
+	}
+
+	state, ok := vm.State.(*ProcessingState)
+	if !ok {
+		t.Fatalf("state = %s, want Processing", stateName(vm.State))
+	}
+	if err := s.InsertMoney(state.Price, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DispenseProduct(); err != nil {
+		t.Fatal(err)
+	}
+	// the machine is free for the next transaction
+	if err := s.SelectProduct("Coke"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	s, vm := newTestService()
+	vm.Products["Water"] = &Product{Name: "Water", Price: 5}
+	vm.Products["Milk"] = &Product{Name: "Milk", Price: 20, Quantity: 1, ExpiresAt: time.Now().Add(-time.Hour)}
+
+	if err := s.SelectProduct("Water"); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("sold out product: got %v, want ErrOutOfStock", err)
+	}
+	if err := s.SelectProduct("Tea"); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("unknown product: got %v, want ErrProductNotFound", err)
+	}
+	if err := s.SelectProduct("Milk"); !errors.Is(err, ErrProductExpired) {
+		t.Errorf("expired product: got %v, want ErrProductExpired", err)
+	}
+	if _, err := s.Reserve("Milk"); !errors.Is(err, ErrProductExpired) {
+		t.Errorf("reserve expired product: got %v, want ErrProductExpired", err)
+	}
+	if err := s.Redeem("R9999"); !errors.Is(err, ErrInvalidPickupCode) {
+		t.Errorf("unknown pickup code: got %v, want ErrInvalidPickupCode", err)
+	}
+	if _, err := s.DispenseProduct(); !errors.Is(err, ErrWrongState) {
+		t.Errorf("dispense while idle: got %v, want ErrWrongState", err)
+	}
+
+	vm.PromoCodes["HALF"] = &PromoCode{Code: "HALF", Percent: 50, ValidUntil: time.Now().Add(time.Hour)}
+	if err := s.SelectProduct("Coke"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ApplyPromo("NOPE"); !errors.Is(err, ErrInvalidPromo) {
+		t.Errorf("unknown promo: got %v, want ErrInvalidPromo", err)
+	}
+	if err := s.ApplyPromo("HALF"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ApplyPromo("HALF"); !errors.Is(err, ErrPromoApplied) {
+		t.Errorf("second promo: got %v, want ErrPromoApplied", err)
+	}
+	if _, err := s.DispenseProduct(); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("dispense before paying: got %v, want ErrInsufficientFunds", err)
+	}
+	if err := s.InsertMoney(5, nil); !errors.Is(err, ErrNoPaymentMethod) {
+		t.Errorf("no payment method: got %v, want ErrNoPaymentMethod", err)
+	}
+}
+
+func TestReceiptForPurchaseWithChange(t *testing.T) {
+	s, _ := newTestService()
+	if err := s.SelectProduct("Coke"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMoney(15, &NotePayment{}); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	receipt, err := s.DispenseProduct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.ProductName != "Coke" || receipt.Price != 10 || receipt.AmountTendered != 15 || receipt.Change != 5 {
+		t.Fatalf("receipt = %+v, want Coke for 10 paid with 15 and 5 change", receipt)
+	}
+	if receipt.Timestamp.Before(before) {
+		t.Fatalf("receipt timestamp %v is before the dispense", receipt.Timestamp)
+	}
+}
+
+func TestExpiredProductRejectedThenRemoved(t *testing.T) {
+	s, vm := newTestService()
+	vm.Products["Milk"] = &Product{Name: "Milk", Price: 20, Quantity: 3, ExpiresAt: time.Now().Add(-time.Minute)}
+
+	err := s.SelectProduct("Milk")
+	if !errors.Is(err, ErrProductExpired) || errors.Is(err, ErrOutOfStock) {
+		t.Fatalf("got %v, want ErrProductExpired and not ErrOutOfStock", err)
+	}
+	if _, idle := vm.State.(*IdleState); !idle {
+		t.Fatalf("state = %s, want Idle", stateName(vm.State))
+	}
+
+	if removed := s.RemoveExpired(); len(removed) != 1 || removed[0] != "Milk" {
+		t.Fatalf("removed %v, want [Milk]", removed)
+	}
+	if _, exists := vm.Products["Milk"]; exists {
+		t.Fatal("Milk still stocked after RemoveExpired")
+	}
+	if err := s.SelectProduct("Milk"); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("got %v, want ErrProductNotFound", err)
+	}
+	if _, exists := vm.Products["Coke"]; !exists {
+		t.Fatal("RemoveExpired dropped a product without expiry")
+	}
+}
+
+func TestPromoReducesAmountOwed(t *testing.T) {
+	s, vm := newTestService()
+	vm.Products["Juice"] = &Product{Name: "Juice", Price: 50, Quantity: 1}
+	vm.PromoCodes["SAVE20"] = &PromoCode{Code: "SAVE20", Percent: 20, ValidUntil: time.Now().Add(time.Hour)}
+	vm.PromoCodes["OLD"] = &PromoCode{Code: "OLD", Percent: 50, ValidUntil: time.Now().Add(-time.Hour)}
+
+	if err := s.SelectProduct("Juice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ApplyPromo("OLD"); !errors.Is(err, ErrInvalidPromo) {
+		t.Fatalf("expired code: got %v, want ErrInvalidPromo", err)
+	}
+	if due := s.AmountDue(); due != 50 {
+		t.Fatalf("due after a rejected code = %d, want 50", due)
+	}
+	if err := s.ApplyPromo("SAVE20"); err != nil {
+		t.Fatal(err)
+	}
+	if due := s.AmountDue(); due != 40 {
+		t.Fatalf("due = %d, want 40", due)
+	}
+
+	if err := s.InsertMoney(50, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := s.DispenseProduct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Price != 40 || receipt.Change != 10 {
+		t.Fatalf("receipt = %+v, want price 40 and change 10", receipt)
+	}
+}
+
+func TestDiagnosticsReportsOutOfStock(t *testing.T) {
+	s, vm := newTestService()
+	vm.Products["Water"] = &Product{Name: "Water", Price: 5}
+	vm.Products["Chips"] = &Product{Name: "Chips", Price: 15, Quantity: 2}
+	if err := s.SelectProduct("Chips"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMoney(10, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := s.Diagnostics()
+	want := Diagnostics{TotalProducts: 3, TotalQuantity: 7, OutOfStock: []string{"Water"}, Balance: 10}
+	if fmt.Sprint(report) != fmt.Sprint(want) {
+		t.Fatalf("report = %+v, want %+v", report, want)
+	}
+}
+
+func TestReservationExpiryRestoresStock(t *testing.T) {
+	s, vm := newTestService()
+	vm.ReservationTTL = 20 * time.Millisecond
+
+	code, err := s.Reserve("Coke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vm.Products["Coke"].Quantity != 4 {
+		t.Fatalf("stock after reserving = %d, want 4", vm.Products["Coke"].Quantity)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if expired := s.ExpireReservations(); len(expired) != 1 || expired[0] != code {
+		t.Fatalf("expired %v, want [%s]", expired, code)
+	}
+	if vm.Products["Coke"].Quantity != 5 {
+		t.Fatalf("stock after expiry = %d, want 5", vm.Products["Coke"].Quantity)
+	}
+	if err := s.Redeem(code); !errors.Is(err, ErrInvalidPickupCode) {
+		t.Fatalf("redeeming an expired code: got %v, want ErrInvalidPickupCode", err)
+	}
+}
+
+func TestRedeemReservation(t *testing.T) {
+	s, vm := newTestService()
+	code, err := s.Reserve("Coke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Redeem(code); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Redeem(code); !errors.Is(err, ErrInvalidPickupCode) {
+		t.Fatalf("redeeming twice: got %v, want ErrInvalidPickupCode", err)
+	}
+	if vm.Products["Coke"].Quantity != 4 {
+		t.Fatalf("stock = %d, want 4", vm.Products["Coke"].Quantity)
+	}
+}
+
+// transitionRecorder keeps the transitions it observed
+type transitionRecorder struct {
+	transitions []string
+}
+
+func (r *transitionRecorder) OnTransition(from, to string) {
+	r.transitions = append(r.transitions, from+"->"+to)
+}
+
+func TestObserverSeesFullPurchase(t *testing.T) {
+	s, _ := newTestService()
+	rec := &transitionRecorder{}
+	s.AddObserver(rec)
+
+	if err := s.SelectProduct("Coke"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMoney(5, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMoney(5, &CoinPayment{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DispenseProduct(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Idle->Processing", "Processing->Dispensing", "Dispensing->Idle"}
+	if fmt.Sprint(rec.transitions) != fmt.Sprint(want) {
+		t.Fatalf("transitions = %v, want %v", rec.transitions, want)
+	}
+}
@@ -27,6 +27,9 @@ var (
 	ErrEmptyRegNo               = errors.New("Vehicle: Resgistartion number should not be empty")
 	ErrEmptyColour              = errors.New("Vehicle: Colour should not be empty")
 	ErrInvalidColour            = errors.New("Vehicle: Invalid Colour")
+	ErrInvalidLayout            = errors.New("Layout: Please give floors and blocks as positive numbers")
+	ErrInvalidFloor             = errors.New("Floor: Invalid floor number")
+	ErrInvalidPricing           = errors.New("Pricing: Please choose one of flat, hourly or day_night")
 )
 
 // ErrInvalidCommand err wrapper
@@ -58,3 +61,8 @@ func ErrNoHistoyFound(h string) error {
 func ErrDuplicateVehicle(regNo string) error {
 	return fmt.Errorf(DuplicateVehicle, regNo)
 }
+
+// Is reports whether err is or wraps target, see the standard errors.Is
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
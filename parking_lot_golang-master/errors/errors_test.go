@@ -34,5 +34,10 @@ var _ = Describe("Errors", func() {
 			res := ErrDuplicateVehicle("ka-01-aj-1234")
 			Expect(res.Error()).To(Equal(fmt.Sprintf(DuplicateVehicle, "ka-01-aj-1234")))
 		})
+		It("Is", func() {
+			wrapped := fmt.Errorf("leave: %w", ErrCarNotFound)
+			Expect(Is(wrapped, ErrCarNotFound)).To(BeTrue())
+			Expect(Is(wrapped, ErrInvalidSlotID)).To(BeFalse())
+		})
 	})
 })
@@ -45,7 +45,7 @@ func setStoreConnection(command *schema.Command) {
 		command.Connection = Store.CreateParkingLot()
 	case string(schema.CMDPark):
 		command.Connection = Store.Park()
-	case string(schema.CMDStatus):
+	case string(schema.CMDStatus), string(schema.CMDStatusJSONL):
 		command.Connection = Store.Status()
 	case string(schema.CMDHelp):
 		command.Connection = Store.Help()
@@ -53,9 +53,10 @@ func setStoreConnection(command *schema.Command) {
 		command.Connection = Store.ShellHistory()
 	case string(schema.CMDParkingHistory):
 		command.Connection = Store.ParkHistory()
-	case string(schema.CMDLeave):
+	case string(schema.CMDLeave), string(schema.CMDLeaveReg):
 		command.Connection = Store.Leave()
-	case "slot_numbers_for_cars_with_colour", "slot_number_for_registration_number", "registration_numbers_for_cars_with_colour":
+	case "slot_numbers_for_cars_with_colour", "slot_number_for_registration_number", "registration_numbers_for_cars_with_colour",
+		string(schema.CMDVehicleDetailsByRegNum), string(schema.CMDAvailableSlots), string(schema.CMDOccupancy):
 		command.Connection = Store.Query()
 	}
 }
@@ -0,0 +1,42 @@
+package schema
+
+import "parking_lot/errors"
+
+// AllocationStrategy picks the slot a vehicle should be parked in
+type AllocationStrategy interface {
+	Allocate(slots []*Slot) (*Slot, error)
+}
+
+// FirstAvailableStrategy returns the first free slot in stored order
+type FirstAvailableStrategy struct{}
+
+// Allocate returns the first available slot in the order the slots are stored
+func (s *FirstAvailableStrategy) Allocate(slots []*Slot) (*Slot, error) {
+	for _, slot := range slots {
+		if slot.IsSlotAvailable() {
+			return slot, nil
+		}
+	}
+	return nil, errors.ErrParkingSlotsFull
+}
+
+// NearestFirstStrategy returns the free slot with the lowest ID,
+// which is the slot closest to the entry point.
+type NearestFirstStrategy struct{}
+
+// Allocate returns the available slot with the lowest slot ID
+func (s *NearestFirstStrategy) Allocate(slots []*Slot) (*Slot, error) {
+	var nearest *Slot
+	for _, slot := range slots {
+		if !slot.IsSlotAvailable() {
+			continue
+		}
+		if nearest == nil || slot.GetID() < nearest.GetID() {
+			nearest = slot
+		}
+	}
+	if nearest == nil {
+		return nil, errors.ErrParkingSlotsFull
+	}
+	return nearest, nil
+}
@@ -0,0 +1,72 @@
+package schema_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"parking_lot/errors"
+	. "parking_lot/schema"
+)
+
+var _ = Describe("Slot allocation strategies", func() {
+	var pl *ParkingLot
+	BeforeEach(func() {
+		// slots are stored out of ID order on purpose
+		pl = new(ParkingLot)
+		for _, id := range []int{3, 1, 2} {
+			slot := new(Slot)
+			slot.SetID(id)
+			slot.SetName(id)
+			slot.MakeSlotFree()
+			pl.Slots = append(pl.Slots, slot)
+		}
+		pl.TotalSlots = len(pl.Slots)
+	})
+	AfterEach(func() {
+		pl = nil
+	})
+
+	It("defaults to the first available slot in stored order", func() {
+		slot, err := pl.FirstAvailableSlot()
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(slot.GetID()).To(Equal(uint(3)))
+	})
+	It("FirstAvailableStrategy keeps the stored order", func() {
+		pl.Allocation = new(FirstAvailableStrategy)
+		slot, err := pl.FirstAvailableSlot()
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(slot.GetID()).To(Equal(uint(3)))
+	})
+	It("NearestFirstStrategy returns the lowest slot ID", func() {
+		pl.Allocation = new(NearestFirstStrategy)
+		slot, err := pl.FirstAvailableSlot()
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(slot.GetID()).To(Equal(uint(1)))
+	})
+	It("NearestFirstStrategy reuses a freed low slot ahead of higher free slots", func() {
+		pl.Allocation = new(NearestFirstStrategy)
+		for i := 0; i < 2; i++ {
+			slot, err := pl.FirstAvailableSlot()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(slot.ParkVehicle(&Vehicle{RegistrationNumber: "KA-01-HH-1234"})).ShouldNot(HaveOccurred())
+		}
+		// slots 1 and 2 are taken, free slot 1 again
+		for _, slot := range pl.Slots {
+			if slot.GetID() == 1 {
+				Ω(slot.ExitPark()).ShouldNot(HaveOccurred())
+			}
+		}
+		slot, err := pl.FirstAvailableSlot()
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(slot.GetID()).To(Equal(uint(1)))
+	})
+	It("NearestFirstStrategy returns parking full error", func() {
+		pl.Allocation = new(NearestFirstStrategy)
+		for _, slot := range pl.Slots {
+			Ω(slot.ParkVehicle(new(Vehicle))).ShouldNot(HaveOccurred())
+		}
+		slot, err := pl.FirstAvailableSlot()
+		Expect(err).To(Equal(errors.ErrParkingSlotsFull))
+		Expect(slot).To(BeNil())
+	})
+})
@@ -35,8 +35,9 @@ func (cmd *Command) Ok() error {
 	if _, ok := ValidCommandsByName[cmd.Command]; !ok {
 		return errors.ErrInvalidCommand(cmd.Command)
 	}
-	if len(cmd.Arguments) != CMDArgumentLength[cmd.Command] {
-		return errors.ErrInvalidArguments(cmd.Command, CMDArgumentLength[cmd.Command], len(cmd.Arguments))
+	required := CMDArgumentLength[cmd.Command]
+	if len(cmd.Arguments) < required || len(cmd.Arguments) > required+CMDOptionalArgumentLength[cmd.Command] {
+		return errors.ErrInvalidArguments(cmd.Command, required, len(cmd.Arguments))
 	}
 
 	return nil
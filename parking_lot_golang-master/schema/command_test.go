@@ -46,5 +46,15 @@ var _ = Describe("Command", func() {
 			err := cmd.Ok()
 			Expect(err).To(BeNil())
 		})
+		It("Validate optional arguments", func() {
+			cmd = &Command{
+				Command:   "status",
+				Arguments: []string{"2"},
+			}
+			Expect(cmd.Ok()).To(BeNil())
+			cmd.Arguments = []string{"1", "2"}
+			err := cmd.Ok()
+			Expect(err).To(Equal(errors.ErrInvalidArguments(cmd.Command, CMDArgumentLength[cmd.Command], len(cmd.Arguments))))
+		})
 	})
 })
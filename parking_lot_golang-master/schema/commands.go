@@ -12,6 +12,8 @@ const (
 	CMDPark CMDType = "park"
 	// CMDStatus command input for get current status of all parking lots
 	CMDStatus CMDType = "status"
+	// CMDStatusJSONL command input for get the occupied slots as newline delimited JSON
+	CMDStatusJSONL CMDType = "status_jsonl"
 	// CMDHelp command input for get help hint for all the commands
 	CMDHelp CMDType = "help"
 	// CMDExit command input to exit from the interactive shell
@@ -22,12 +24,23 @@ const (
 	CMDParkingHistory CMDType = "park_history"
 
 	CMDLeave CMDType = "leave"
+	// CMDLeaveReg command input to free the slot holding a registration number
+	CMDLeaveReg CMDType = "leave_reg"
 
 	CMDSlotNumberByCarColor = "slot_numbers_for_cars_with_colour"
 
 	CMDSlotNoByRegNum = "slot_number_for_registration_number"
 
 	CMDregistration_numbers_for_cars_with_colour = "registration_numbers_for_cars_with_colour"
+
+	// CMDVehicleDetailsByRegNum command input to get the slot, location, colour
+	// and parked duration of a vehicle
+	CMDVehicleDetailsByRegNum = "vehicle_details_for_registration_number"
+
+	// CMDAvailableSlots command input to get the count of free slots
+	CMDAvailableSlots = "available_slots"
+	// CMDOccupancy command input to get the count of occupied slots out of the total
+	CMDOccupancy = "occupancy"
 )
 
 // ValidCommandsByName holds the valid commands map
@@ -40,9 +53,14 @@ var ValidCommandsByName = map[string]bool{
 	string(CMDShellHistory):         true,
 	string(CMDParkingHistory):       true,
 	string(CMDLeave):                true,
+	string(CMDLeaveReg):             true,
 	string(CMDSlotNumberByCarColor): true,
 	string(CMDSlotNoByRegNum):       true,
 	string(CMDregistration_numbers_for_cars_with_colour): true,
+	string(CMDVehicleDetailsByRegNum):                    true,
+	string(CMDAvailableSlots):                            true,
+	string(CMDOccupancy):                                 true,
+	string(CMDStatusJSONL):                               true,
 }
 
 // CMDArgumentLength holds the exact arguments length to read for commands
@@ -55,7 +73,19 @@ var CMDArgumentLength = map[string]int{
 	string(CMDShellHistory):         0,
 	string(CMDParkingHistory):       0,
 	string(CMDLeave):                1,
+	string(CMDLeaveReg):             1,
 	string(CMDSlotNumberByCarColor): 1,
 	string(CMDSlotNoByRegNum):       1,
 	string(CMDregistration_numbers_for_cars_with_colour): 1,
+	string(CMDVehicleDetailsByRegNum):                    1,
+	string(CMDAvailableSlots):                            0,
+	string(CMDOccupancy):                                 0,
+	string(CMDStatusJSONL):                               0,
+}
+
+// CMDOptionalArgumentLength holds the number of extra arguments a command
+// may take on top of CMDArgumentLength
+var CMDOptionalArgumentLength = map[string]int{
+	string(CMDCreateParkingLot): 3,
+	string(CMDStatus):           1,
 }
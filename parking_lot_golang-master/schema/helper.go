@@ -18,17 +18,35 @@ var AllCommendHint = `
 Available commands:
     ●   create_parking_lot
             To create a parking lot with N slots.
-            'create_parking_lot {no.of slots to create}'
+            'create_parking_lot {no.of slots to create} [{no.of floors} {blocks per floor}] [{pricing}]'
+            pricing is one of flat, hourly or day_night, charged on leave
             Eg: 'create_parking_lot 6'
+            Eg: 'create_parking_lot 12 2 3'
+            Eg: 'create_parking_lot 12 2 3 hourly'
             Eg: 'create_parking_lot help' to get help
     ●   park
             To park a vehicle, the system will allocate parking slot to park.
             'park {registration number} { vehicle colur}'
             Eg: 'park​ KA-01-HH-1234​ ​White'
             Eg: 'park help' to get help
+    ●   leave
+            To free the slot of a parked vehicle.
+            'leave {slot number}'
+            Eg: 'leave 4'
+            Eg: 'leave help' to get help
+    ●   leave_reg
+            To free the slot of a parked vehicle by its registration number.
+            'leave_reg {registration number}'
+            Eg: 'leave_reg KA-01-HH-1234'
+            Eg: 'leave_reg help' to get help
     ●   status
             To get the current status of the all parking slots.
+            'status [{floor}]'
             Eg: 'status'
+            Eg: 'status 2' to get the slots of floor 2
+    ●   status_jsonl
+            To get the occupied slots as one JSON object per line.
+            Eg: 'status_jsonl'
     ●   help
             To get all the availabe commands to use.
             Eg: 'help'
@@ -47,8 +65,11 @@ Available commands:
 var CMDCreateParkingLotHint = `
 ●   create_parking_lot
         To create a parking lot with N slots.
-        'create_parking_lot {no.of slots to create}'
+        'create_parking_lot {no.of slots to create} [{no.of floors} {blocks per floor}] [{pricing}]'
+        pricing is one of flat, hourly or day_night, charged on leave
         Eg: 'create_parking_lot 6'
+        Eg: 'create_parking_lot 12 2 3'
+        Eg: 'create_parking_lot 12 2 3 hourly'
 `
 
 // CMDParkHint holds help message for `park`
@@ -59,11 +80,29 @@ var CMDParkHint = `
         Eg: 'park​ KA-01-HH-1234​ ​White'
 `
 
+// CMDLeaveHint holds help message for `leave`
+var CMDLeaveHint = `
+●   leave
+        To free the slot of a parked vehicle.
+        'leave {slot number}'
+        Eg: 'leave 4'
+`
+
+// CMDLeaveRegHint holds help message for `leave_reg`
+var CMDLeaveRegHint = `
+●   leave_reg
+        To free the slot of a parked vehicle by its registration number.
+        'leave_reg {registration number}'
+        Eg: 'leave_reg KA-01-HH-1234'
+`
+
 // CMDstatusHint holds help message for `status`
 var CMDstatusHint = `
 ●   status
         To get the current status of the all parking slots.
+        'status [{floor}]'
         Eg: 'status'
+        Eg: 'status 2' to get the slots of floor 2
 `
 
 // CMDHelpHint holds help message for `help`
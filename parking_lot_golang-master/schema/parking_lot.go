@@ -1,7 +1,7 @@
 package schema
 
 import (
-	"parking_lot/errors"
+	"fmt"
 	"time"
 )
 
@@ -9,6 +9,7 @@ import (
 type ParkingLot struct {
 	Name        string         `json:"name"`
 	Floor       string         `json:"floor"`
+	TotalFloors int            `json:"total_floors"`
 	TotalBlocks int            `json:"total_blocks"`
 	BlockHeight int            `json:"block_height"`
 	TotalSlots  int            `json:"total_slots"`
@@ -16,6 +17,13 @@ type ParkingLot struct {
 	Pincode     string         `json:"pincode"`
 	Slots       []*Slot        `json:"slots"`
 	ParkHistory []*ParkHistory `json:"park_history"`
+
+	// Allocation decides which free slot gets the next vehicle,
+	// defaults to FirstAvailableStrategy when not set
+	Allocation AllocationStrategy `json:"-"`
+	// Pricing computes the charge when a vehicle leaves,
+	// nothing is charged when not set
+	Pricing PricingStrategy `json:"-"`
 }
 
 // ParkHistory holds the parking information
@@ -26,15 +34,66 @@ type ParkHistory struct {
 	CreatedAt          time.Time
 }
 
-// FirstAvailableSlot returns the first available slot to park Vehicle
+// FirstAvailableSlot returns the available slot to park Vehicle
+// picked by the parking lot allocation strategy
 func (pl *ParkingLot) FirstAvailableSlot() (*Slot, error) {
+	if pl.Allocation == nil {
+		return new(FirstAvailableStrategy).Allocate(pl.Slots)
+	}
+	return pl.Allocation.Allocate(pl.Slots)
+}
+
+// LayoutSlots creates TotalSlots free slots numbered 1 to N and lays them
+// out evenly across the floors, TotalBlocks blocks per floor.
+// Lower slot numbers are on the lower floors and the earlier blocks.
+func (pl *ParkingLot) LayoutSlots() {
+	floors, blocks := pl.TotalFloors, pl.TotalBlocks
+	if floors <= 0 {
+		floors = 1
+	}
+	if blocks <= 0 {
+		blocks = 1
+	}
+	// slots per block, rounded up so every slot gets a block
+	perBlock := (pl.TotalSlots + floors*blocks - 1) / (floors * blocks)
+
+	pl.Slots = make([]*Slot, pl.TotalSlots)
+	for i := range pl.Slots {
+		blockIdx := i / perBlock
+		pl.Slots[i] = new(Slot)
+		pl.Slots[i].SetID(i + 1)
+		pl.Slots[i].SetName(i + 1)
+		pl.Slots[i].FloorID = uint(blockIdx/blocks + 1)
+		pl.Slots[i].BlockID = uint(blockIdx%blocks + 1)
+		pl.Slots[i].BlockName = fmt.Sprintf("%c-Block", 'A'+blockIdx%blocks)
+		pl.Slots[i].MakeSlotFree()
+	}
+}
+
+// GetSlotsByFloor returns all the slots laid out on the given floor
+func (pl *ParkingLot) GetSlotsByFloor(floor int) []*Slot {
+	var slots []*Slot
 	for _, slot := range pl.Slots {
-		if slot.IsSlotAvailable() {
-			return slot, nil
+		if int(slot.FloorID) == floor {
+			slots = append(slots, slot)
 		}
 	}
+	return slots
+}
 
-	return nil, errors.ErrParkingSlotsFull
+// ParkedSince returns when the vehicle in the slot was parked,
+// from its latest park history in the slot
+func (pl *ParkingLot) ParkedSince(slot *Slot) (time.Time, bool) {
+	if slot.Vehicle == nil {
+		return time.Time{}, false
+	}
+	for i := len(pl.ParkHistory) - 1; i >= 0; i-- {
+		history := pl.ParkHistory[i]
+		if history.SlotID == slot.GetID() && history.RegistrationNumber == slot.Vehicle.RegistrationNumber {
+			return history.CreatedAt, true
+		}
+	}
+	return time.Time{}, false
 }
 
 func (pl *ParkingLot) GetSlotByID(id int) *Slot {
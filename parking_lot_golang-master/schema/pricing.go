@@ -0,0 +1,80 @@
+package schema
+
+import "time"
+
+// Pricing strategy names accepted by `create_parking_lot`
+const (
+	PricingFlat     = "flat"
+	PricingHourly   = "hourly"
+	PricingDayNight = "day_night"
+)
+
+// PricingStrategy computes the charge for a vehicle parked from `from` to `to`
+type PricingStrategy interface {
+	Charge(from, to time.Time) int
+}
+
+// FlatRate charges the same fee however long the vehicle stays
+type FlatRate struct {
+	Fee int
+}
+
+// Charge returns the flat fee
+func (r *FlatRate) Charge(from, to time.Time) int {
+	return r.Fee
+}
+
+// HourlyRate charges for every started hour, with a minimum of one hour
+type HourlyRate struct {
+	PerHour int
+}
+
+// Charge returns the started hours times the hourly rate
+func (r *HourlyRate) Charge(from, to time.Time) int {
+	return startedHours(from, to) * r.PerHour
+}
+
+// DayNightRate charges every started hour at the day rate when it starts
+// between DayStart and NightStart (hours of the day), else at the night rate
+type DayNightRate struct {
+	DayPerHour   int
+	NightPerHour int
+	DayStart     int
+	NightStart   int
+}
+
+// Charge adds up the started hours, each priced by the hour of day it starts in
+func (r *DayNightRate) Charge(from, to time.Time) int {
+	total := 0
+	for i := 0; i < startedHours(from, to); i++ {
+		hour := from.Add(time.Duration(i) * time.Hour).Hour()
+		if hour >= r.DayStart && hour < r.NightStart {
+			total += r.DayPerHour
+		} else {
+			total += r.NightPerHour
+		}
+	}
+	return total
+}
+
+// startedHours returns the number of hours begun between from and to, at least one
+func startedHours(from, to time.Time) int {
+	hours := int((to.Sub(from) + time.Hour - 1) / time.Hour)
+	if hours < 1 {
+		return 1
+	}
+	return hours
+}
+
+// PricingStrategyByName returns the default rates for a pricing strategy name
+func PricingStrategyByName(name string) (PricingStrategy, bool) {
+	switch name {
+	case PricingFlat:
+		return &FlatRate{Fee: 50}, true
+	case PricingHourly:
+		return &HourlyRate{PerHour: 10}, true
+	case PricingDayNight:
+		return &DayNightRate{DayPerHour: 10, NightPerHour: 5, DayStart: 6, NightStart: 22}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package schema_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "parking_lot/schema"
+)
+
+var _ = Describe("Pricing strategies", func() {
+	// parked for 3h30m from 20:00, the last two started hours fall in the night
+	from := time.Date(2020, 1, 1, 20, 0, 0, 0, time.UTC)
+	to := from.Add(3*time.Hour + 30*time.Minute)
+
+	It("FlatRate charges the same fee for any duration", func() {
+		rate := &FlatRate{Fee: 50}
+		Expect(rate.Charge(from, to)).To(Equal(50))
+		Expect(rate.Charge(from, from)).To(Equal(50))
+	})
+	It("HourlyRate charges every started hour", func() {
+		rate := &HourlyRate{PerHour: 10}
+		Expect(rate.Charge(from, to)).To(Equal(40))
+		Expect(rate.Charge(from, from.Add(2*time.Hour))).To(Equal(20))
+	})
+	It("HourlyRate charges at least one hour", func() {
+		rate := &HourlyRate{PerHour: 10}
+		Expect(rate.Charge(from, from)).To(Equal(10))
+	})
+	It("DayNightRate prices each started hour by the hour of day it starts in", func() {
+		rate := &DayNightRate{DayPerHour: 10, NightPerHour: 5, DayStart: 6, NightStart: 22}
+		Expect(rate.Charge(from, to)).To(Equal(10 + 10 + 5 + 5))
+	})
+	It("PricingStrategyByName knows the lot pricing names", func() {
+		for _, name := range []string{PricingFlat, PricingHourly, PricingDayNight} {
+			_, ok := PricingStrategyByName(name)
+			Expect(ok).To(BeTrue())
+		}
+		_, ok := PricingStrategyByName("weekly")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -12,6 +12,7 @@ type Slot struct {
 	IsFree    bool     `json:"is_free"`
 	BlockName string   `json:"block_name"`
 	BlockID   uint     `json:"block_id"`
+	FloorID   uint     `json:"floor_id"`
 	Vehicle   *Vehicle `json:"vehicle"`
 }
 
@@ -31,6 +31,9 @@ func (pl *createParkingLotStore) IsHelp(arg string) (string, bool) {
 // The system will check if no parking_lot availabe then it create a parking_lot
 // with N slots.
 // All the slots will initialized with sequence slot numbers by start 1 to N
+// Optionally it takes the number of floors and blocks per floor to lay the
+// slots across, eg: 'create_parking_lot 12 2 3'
+// and last the pricing strategy charged on leave, eg: 'create_parking_lot 12 2 3 hourly'
 func (pl *createParkingLotStore) Execute(cmd *schema.Command) (string, error) {
 	if res, isHelp := pl.IsHelp(cmd.Arguments[0]); isHelp {
 		return res, nil
@@ -42,29 +45,58 @@ func (pl *createParkingLotStore) Execute(cmd *schema.Command) (string, error) {
 	if totalSlots <= 0 {
 		return "", errors.ErrInvalidSlotCount(totalSlots)
 	}
+	layoutArgs := cmd.Arguments[1:]
+	var pricing schema.PricingStrategy
+	// a trailing non numeric argument names the pricing strategy
+	if n := len(layoutArgs); n > 0 {
+		if _, err := strconv.Atoi(layoutArgs[n-1]); err != nil {
+			var ok bool
+			if pricing, ok = schema.PricingStrategyByName(layoutArgs[n-1]); !ok {
+				return "", errors.ErrInvalidPricing
+			}
+			layoutArgs = layoutArgs[:n-1]
+		}
+	}
+	totalFloors, totalBlocks := 1, 1
+	if len(layoutArgs) > 0 {
+		if totalFloors, totalBlocks, err = parseLayout(layoutArgs); err != nil {
+			return "", err
+		}
+	}
 	if ParkingLot != nil {
 		return "", errors.ErrParkingLotAlreadyCreated
 	}
 	newLot := &schema.ParkingLot{
 		Name:        parkingLotName,
 		Floor:       "ground_floor",
-		TotalBlocks: 1,
+		TotalFloors: totalFloors,
+		TotalBlocks: totalBlocks,
 		BlockHeight: 12, // feet
 		TotalSlots:  totalSlots,
-		Slots:       make([]*schema.Slot, totalSlots),
+		Allocation:  new(schema.NearestFirstStrategy),
+		Pricing:     pricing,
 	}
 
-	// initiate nil slot properties
-	for i := range newLot.Slots {
-		newLot.Slots[i] = new(schema.Slot)
-		newLot.Slots[i].SetID(i + 1)
-		newLot.Slots[i].SetName(i + 1)
-		newLot.Slots[i].BlockID = 1
-		newLot.Slots[i].BlockName = "A-Block"
-		newLot.Slots[i].MakeSlotFree()
-	}
+	// initiate slot properties across floors and blocks
+	newLot.LayoutSlots()
 
 	// set parking lot info global
 	ParkingLot = newLot
 	return fmt.Sprintf(ParkinglotCreatedInfo, totalSlots), nil
 }
+
+// parseLayout reads the number of floors and blocks per floor
+func parseLayout(args []string) (floors, blocks int, err error) {
+	if len(args) != 2 {
+		return 0, 0, errors.ErrInvalidLayout
+	}
+	floors, err = strconv.Atoi(args[0])
+	if err != nil || floors <= 0 {
+		return 0, 0, errors.ErrInvalidLayout
+	}
+	blocks, err = strconv.Atoi(args[1])
+	if err != nil || blocks <= 0 {
+		return 0, 0, errors.ErrInvalidLayout
+	}
+	return floors, blocks, nil
+}
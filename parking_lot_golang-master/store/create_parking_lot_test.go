@@ -72,4 +72,67 @@ var _ = Describe("parking lot store tests", func() {
 			Expect(res).To(Equal(""))
 		})
 	})
+
+	Context("parking_lot across floors and blocks", func() {
+		cmd := &schema.Command{
+			Command: "create_parking_lot",
+		}
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("invalid floors and blocks", func() {
+			cmd.Arguments = []string{"8", "two", "2"}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrInvalidLayout))
+			Expect(res).To(Equal(""))
+
+			cmd.Arguments = []string{"8", "2"}
+			res, err = connection.CreateParkingLot().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrInvalidLayout))
+			Expect(res).To(Equal(""))
+
+			cmd.Arguments = []string{"8", "2", "0"}
+			res, err = connection.CreateParkingLot().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrInvalidLayout))
+			Expect(res).To(Equal(""))
+		})
+
+		It("Create a parking lot with 8 slots on 2 floors of 2 blocks", func() {
+			cmd.Arguments = []string{"8", "2", "2"}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 8)))
+			Expect(ParkingLot.TotalFloors).To(Equal(2))
+			Expect(ParkingLot.TotalBlocks).To(Equal(2))
+			Expect(len(ParkingLot.GetSlotsByFloor(1))).To(Equal(4))
+			Expect(len(ParkingLot.GetSlotsByFloor(2))).To(Equal(4))
+		})
+
+		It("allocation spreads across blocks and floors", func() {
+			expected := []struct {
+				floor, block uint
+				blockName    string
+			}{
+				{1, 1, "A-Block"}, {1, 1, "A-Block"},
+				{1, 2, "B-Block"}, {1, 2, "B-Block"},
+				{2, 1, "A-Block"},
+			}
+			regNos := []string{"KA-01-HH-1234", "KA-01-HH-9999", "KA-01-BB-0001", "KA-01-HH-7777", "KA-01-HH-2701"}
+			for i, regNo := range regNos {
+				parkCmd := &schema.Command{
+					Command:   "park",
+					Arguments: []string{regNo, "White"},
+				}
+				res, err := connection.Park().Execute(parkCmd)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(res).To(Equal(fmt.Sprintf(SlotAllocatedInfo, i+1)))
+
+				slot := ParkingLot.GetSlotByID(i + 1)
+				Expect(slot.FloorID).To(Equal(expected[i].floor))
+				Expect(slot.BlockID).To(Equal(expected[i].block))
+				Expect(slot.BlockName).To(Equal(expected[i].blockName))
+			}
+		})
+	})
 })
@@ -5,6 +5,7 @@ import (
 	"parking_lot/errors"
 	"parking_lot/schema"
 	"strconv"
+	"time"
 )
 
 type leaveStore struct {
@@ -18,39 +19,63 @@ func NewLeaveStore(st *store) *leaveStore {
 
 func (pl *leaveStore) IsHelp(arg string) (string, bool) {
 	if arg == string(schema.CMDHelp) {
-		return schema.CMDParkHint, true
+		return schema.CMDLeaveHint, true
 	}
 	return "", false
 }
 
 // Execute - `leave` command takes a slot number as an argument,
 // and makes it available for future parking.
+// `leave_reg` takes a registration number instead and frees the slot holding it.
 func (ls *leaveStore) Execute(cmd *schema.Command) (string, error) {
+	isByReg := cmd.Command == schema.CMDLeaveReg
+	if len(cmd.Arguments) == 0 {
+		if isByReg {
+			return "", errors.ErrEmptyRegNo
+		}
+		return "", errors.ErrInvalidSlotID
+	}
 	if res, isHelp := ls.IsHelp(cmd.Arguments[0]); isHelp {
+		if isByReg {
+			return schema.CMDLeaveRegHint, nil
+		}
 		return res, nil
 	}
 	if ParkingLot == nil {
 		return "", errors.ErrNoParkingLot
 	}
-	// Validate slot ID
-	slotID, err := strconv.Atoi(cmd.Arguments[0])
-	if err != nil || slotID <= 0 {
-		return "", errors.ErrInvalidSlotID
-	}
-
-	slot := ParkingLot.GetSlotByID(slotID)
-	if slot == nil {
-		return "", errors.ErrInvalidSlotID
+	var slot *schema.Slot
+	if isByReg {
+		var err error
+		if slot, err = slotByRegNumber(cmd.Arguments[0]); err != nil {
+			return "", err
+		}
+	} else {
+		// Validate slot ID
+		slotID, err := strconv.Atoi(cmd.Arguments[0])
+		if err != nil || slotID <= 0 {
+			return "", errors.ErrInvalidSlotID
+		}
+		slot = ParkingLot.GetSlotByID(slotID)
+		if slot == nil {
+			return "", errors.ErrInvalidSlotID
+		}
 	}
 	if slot.IsSlotAvailable() {
 		return "", errors.ErrInvalidSlotID
 	}
 
+	// Charge the vehicle by the lot pricing before it leaves
+	parkedAt, parked := ParkingLot.ParkedSince(slot)
+
 	// Remove the vehicle
 	vehicle := slot.GetParkedVehicle()
-	err = slot.RemoveVehicle()
-	if err != nil {
+	if err := slot.RemoveVehicle(); err != nil {
 		return "", err
 	}
+	if ParkingLot.Pricing != nil && parked {
+		charge := ParkingLot.Pricing.Charge(parkedAt, time.Now())
+		return fmt.Sprintf("done %s, charge: %d", vehicle.RegistrationNumber, charge), nil
+	}
 	return fmt.Sprintf("done %s", vehicle.RegistrationNumber), nil
 }
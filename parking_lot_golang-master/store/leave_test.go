@@ -0,0 +1,211 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"parking_lot/errors"
+	"parking_lot/schema"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parking lot store tests", func() {
+	var (
+		connection Store
+	)
+	connection = NewStore()
+	It("Tear Down Store Data", func() {
+		TearDown()
+	})
+
+	Context("leave store execute", func() {
+		It("leave help", func() {
+			cmd := &schema.Command{
+				Command:   "leave",
+				Arguments: []string{"help"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(schema.CMDLeaveHint))
+		})
+
+		It("leave without arguments", func() {
+			cmd := &schema.Command{
+				Command: "leave",
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrInvalidSlotID))
+			Expect(res).To(Equal(""))
+		})
+
+		It("No parking lot available", func() {
+			cmd := &schema.Command{
+				Command:   "leave",
+				Arguments: []string{"1"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrNoParkingLot))
+			Expect(res).To(Equal(""))
+		})
+
+		It("Create a parking lot with 2 slots", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"2"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 2)))
+		})
+
+		It("park a vehicle", func() {
+			cmd := &schema.Command{
+				Command:   "park",
+				Arguments: []string{"TN-24-AJ-8462", "Red"},
+			}
+			res, err := connection.Park().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("Allocated slot number: 1"))
+		})
+
+		It("leave an invalid slot", func() {
+			for _, slotID := range []string{"abc", "0", "-1", "3"} {
+				cmd := &schema.Command{
+					Command:   "leave",
+					Arguments: []string{slotID},
+				}
+				res, err := connection.Leave().Execute(cmd)
+				Expect(err).To(Equal(errors.ErrInvalidSlotID))
+				Expect(res).To(Equal(""))
+			}
+		})
+
+		It("leave an occupied slot", func() {
+			cmd := &schema.Command{
+				Command:   "leave",
+				Arguments: []string{"1"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("done TN-24-AJ-8462"))
+			Expect(ParkingLot.GetSlotByID(1).IsSlotAvailable()).To(BeTrue())
+		})
+
+		It("leave an already empty slot", func() {
+			cmd := &schema.Command{
+				Command:   "leave",
+				Arguments: []string{"1"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrInvalidSlotID))
+			Expect(res).To(Equal(""))
+		})
+	})
+
+	Context("leave by registration number", func() {
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("leave_reg help", func() {
+			cmd := &schema.Command{
+				Command:   "leave_reg",
+				Arguments: []string{"help"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(schema.CMDLeaveRegHint))
+		})
+
+		It("Create a parking lot with 2 slots", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"2"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 2)))
+		})
+
+		It("park two vehicles", func() {
+			for _, regNo := range []string{"KA-01-HH-1234", "KA-01-HH-9999"} {
+				cmd := &schema.Command{
+					Command:   "park",
+					Arguments: []string{regNo, "White"},
+				}
+				_, err := connection.Park().Execute(cmd)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+		})
+
+		It("leave by the plate of the second vehicle", func() {
+			cmd := &schema.Command{
+				Command:   "leave_reg",
+				Arguments: []string{"KA-01-HH-9999"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("done KA-01-HH-9999"))
+			Expect(ParkingLot.GetSlotByID(2).IsSlotAvailable()).To(BeTrue())
+			Expect(ParkingLot.GetSlotByID(1).IsSlotAvailable()).To(BeFalse())
+		})
+
+		It("leave by a plate that isn't parked", func() {
+			cmd := &schema.Command{
+				Command:   "leave_reg",
+				Arguments: []string{"KA-01-HH-9999"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrCarNotFound))
+			Expect(res).To(Equal(""))
+		})
+	})
+
+	Context("leave charges by the lot pricing", func() {
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("invalid pricing", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"2", "weekly"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Expect(err).To(Equal(errors.ErrInvalidPricing))
+			Expect(res).To(Equal(""))
+		})
+
+		It("Create a parking lot with 2 slots and hourly pricing", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"2", "hourly"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 2)))
+			Expect(ParkingLot.Pricing).To(Equal(&schema.HourlyRate{PerHour: 10}))
+		})
+
+		It("leave charges the started hours", func() {
+			cmd := &schema.Command{
+				Command:   "park",
+				Arguments: []string{"KA-01-HH-1234", "White"},
+			}
+			_, err := connection.Park().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			// parked 2h30m ago
+			ParkingLot.ParkHistory[0].CreatedAt = time.Now().Add(-150 * time.Minute)
+
+			cmd = &schema.Command{
+				Command:   "leave",
+				Arguments: []string{"1"},
+			}
+			res, err := connection.Leave().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("done KA-01-HH-1234, charge: 30"))
+		})
+	})
+})
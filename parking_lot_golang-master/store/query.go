@@ -2,9 +2,11 @@ package store
 
 import (
 	"fmt"
+	"parking_lot/errors"
 	"parking_lot/schema"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Query struct {
@@ -19,7 +21,12 @@ func NewQueryStore(st *store) *Query {
 
 func (qc *Query) Execute(cmd *schema.Command) (string, error) {
 	queryStrategy := qc.getQueryStrategy(cmd)
-	resp, err := queryStrategy.ExecuteQuery(cmd.Arguments[0])
+	// count queries take no key
+	var key string
+	if len(cmd.Arguments) > 0 {
+		key = cmd.Arguments[0]
+	}
+	resp, err := queryStrategy.ExecuteQuery(key)
 	if err != nil {
 		return "", err
 	}
@@ -34,6 +41,12 @@ func (qc *Query) getQueryStrategy(cmd *schema.Command) QueryStrategy {
 		return &SlotNumbersByColourHandler{qc.store}
 	case string("slot_number_for_registration_number"):
 		return &SlotNumberByRegHandler{qc.store}
+	case string(schema.CMDVehicleDetailsByRegNum):
+		return &VehicleDetailsByRegHandler{qc.store}
+	case string(schema.CMDAvailableSlots):
+		return &AvailableSlotsHandler{qc.store}
+	case string(schema.CMDOccupancy):
+		return &OccupancyHandler{qc.store}
 	default:
 		return nil
 	}
@@ -82,10 +95,102 @@ type SlotNumberByRegHandler struct {
 }
 
 func (h *SlotNumberByRegHandler) ExecuteQuery(key string) (interface{}, error) {
+	slot, err := slotByRegNumber(key)
+	if errors.Is(err, errors.ErrCarNotFound) {
+		return "Not found", nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strconv.Itoa(int(slot.ID)), nil
+}
+
+// slotByRegNumber returns the slot the vehicle is parked in, ErrCarNotFound
+// when it isn't parked
+func slotByRegNumber(regNo string) (*schema.Slot, error) {
+	for _, slot := range ParkingLot.Slots {
+		if slot.Vehicle != nil && strings.EqualFold(slot.Vehicle.RegistrationNumber, regNo) {
+			return slot, nil
+		}
+	}
+	return nil, errors.ErrCarNotFound
+}
+
+// VehicleDetails holds where a vehicle is parked and for how long
+type VehicleDetails struct {
+	SlotID             uint
+	FloorID            uint
+	BlockName          string
+	RegistrationNumber string
+	Colour             string
+	ParkedFor          time.Duration
+}
+
+func (d VehicleDetails) String() string {
+	return fmt.Sprintf("Slot No.: %d, Floor: %d, Block: %s, Registration No: %s, Colour: %s, Parked for: %s",
+		d.SlotID, d.FloorID, d.BlockName, d.RegistrationNumber, d.Colour, d.ParkedFor)
+}
+
+type VehicleDetailsByRegHandler struct {
+	*store
+}
+
+func (h *VehicleDetailsByRegHandler) ExecuteQuery(key string) (interface{}, error) {
+	if ParkingLot == nil {
+		return nil, errors.ErrNoParkingLot
+	}
+	slot, err := slotByRegNumber(key)
+	if errors.Is(err, errors.ErrCarNotFound) {
+		return "Not found", nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	details := VehicleDetails{
+		SlotID:             slot.GetID(),
+		FloorID:            slot.FloorID,
+		BlockName:          slot.BlockName,
+		RegistrationNumber: slot.Vehicle.GetRegNumber(),
+		Colour:             slot.Vehicle.GetColour(),
+	}
+	if parkedAt, ok := ParkingLot.ParkedSince(slot); ok {
+		details.ParkedFor = time.Since(parkedAt).Round(time.Second)
+	}
+	return details, nil
+}
+
+// AvailableSlotsHandler counts the free slots
+type AvailableSlotsHandler struct {
+	*store
+}
+
+func (h *AvailableSlotsHandler) ExecuteQuery(key string) (interface{}, error) {
+	if ParkingLot == nil {
+		return nil, errors.ErrNoParkingLot
+	}
+	count := 0
+	for _, slot := range ParkingLot.Slots {
+		if slot.IsSlotAvailable() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// OccupancyHandler returns the occupied slots out of the total, eg. 1/3
+type OccupancyHandler struct {
+	*store
+}
+
+func (h *OccupancyHandler) ExecuteQuery(key string) (interface{}, error) {
+	if ParkingLot == nil {
+		return nil, errors.ErrNoParkingLot
+	}
+	occupied := 0
 	for _, slot := range ParkingLot.Slots {
-		if slot.Vehicle != nil && strings.EqualFold(slot.Vehicle.RegistrationNumber, key) {
-			return strconv.Itoa(int(slot.ID)), nil
+		if slot.IsSlotOccupied() {
+			occupied++
 		}
 	}
-	return "Not found", nil
+	return fmt.Sprintf("%d/%d", occupied, len(ParkingLot.Slots)), nil
 }
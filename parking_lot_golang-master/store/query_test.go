@@ -3,6 +3,7 @@ package store
 import (
 	"fmt"
 
+	"parking_lot/errors"
 	"parking_lot/schema"
 
 	. "github.com/onsi/ginkgo"
@@ -50,4 +51,129 @@ var _ = Describe("parking lot store tests", func() {
 			Expect(res).To(Equal(res))
 		})
 	})
+
+	Context("vehicle details by registration number", func() {
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("No parking lot available", func() {
+			handler := &VehicleDetailsByRegHandler{}
+			res, err := handler.ExecuteQuery("KA-01-HH-1234")
+			Expect(err).To(Equal(errors.ErrNoParkingLot))
+			Expect(res).To(BeNil())
+		})
+
+		It("Create a parking lot with 4 slots on 2 floors", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"4", "2", "1"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 4)))
+		})
+
+		It("park vehicles", func() {
+			for _, regNo := range []string{"KA-01-HH-1234", "KA-01-HH-9999", "KA-01-BB-0001"} {
+				cmd := &schema.Command{
+					Command:   "park",
+					Arguments: []string{regNo, "White"},
+				}
+				_, err := connection.Park().Execute(cmd)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+		})
+
+		It("returns all the details of a parked vehicle", func() {
+			handler := &VehicleDetailsByRegHandler{}
+			res, err := handler.ExecuteQuery("KA-01-BB-0001")
+			Ω(err).ShouldNot(HaveOccurred())
+			details, ok := res.(VehicleDetails)
+			Expect(ok).To(BeTrue())
+			Expect(details.SlotID).To(Equal(uint(3)))
+			Expect(details.FloorID).To(Equal(uint(2)))
+			Expect(details.BlockName).To(Equal("A-Block"))
+			Expect(details.RegistrationNumber).To(Equal("KA-01-BB-0001"))
+			Expect(details.Colour).To(Equal("white"))
+			Expect(details.ParkedFor).To(BeNumerically(">=", 0))
+		})
+
+		It("formats the details through the query command", func() {
+			cmd := &schema.Command{
+				Command:   schema.CMDVehicleDetailsByRegNum,
+				Arguments: []string{"KA-01-HH-1234"},
+			}
+			res, err := connection.Query().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(HavePrefix("Slot No.: 1, Floor: 1, Block: A-Block, Registration No: KA-01-HH-1234, Colour: white, Parked for: "))
+		})
+
+		It("vehicle not found", func() {
+			cmd := &schema.Command{
+				Command:   schema.CMDVehicleDetailsByRegNum,
+				Arguments: []string{"KA-01-ZZ-0000"},
+			}
+			res, err := connection.Query().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("Not found"))
+		})
+	})
+
+	Context("slot availability queries", func() {
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("No parking lot available", func() {
+			for _, command := range []string{schema.CMDAvailableSlots, schema.CMDOccupancy} {
+				cmd := &schema.Command{
+					Command: command,
+				}
+				res, err := connection.Query().Execute(cmd)
+				Expect(err).To(Equal(errors.ErrNoParkingLot))
+				Expect(res).To(Equal(""))
+			}
+		})
+
+		It("Create a parking lot with 3 slots", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"3"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 3)))
+		})
+
+		It("park a vehicle", func() {
+			cmd := &schema.Command{
+				Command:   "park",
+				Arguments: []string{"KA-01-HH-1234", "White"},
+			}
+			res, err := connection.Park().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("Allocated slot number: 1"))
+		})
+
+		It("available_slots", func() {
+			cmd := &schema.Command{
+				Command: schema.CMDAvailableSlots,
+			}
+			Ω(cmd.Ok()).ShouldNot(HaveOccurred())
+			res, err := connection.Query().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("2"))
+		})
+
+		It("occupancy", func() {
+			cmd := &schema.Command{
+				Command: schema.CMDOccupancy,
+			}
+			Ω(cmd.Ok()).ShouldNot(HaveOccurred())
+			res, err := connection.Query().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("1/3"))
+		})
+	})
 })
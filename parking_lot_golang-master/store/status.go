@@ -1,7 +1,9 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"parking_lot/errors"
@@ -20,12 +22,27 @@ func NewStatusStore(st *store) *statusStore {
 }
 
 // Execute will returns the current status of all the slots.
+// `status {floor}` returns the status of the slots on that floor only.
 func (pl *statusStore) Execute(cmd *schema.Command) (string, error) {
 	if ParkingLot == nil {
 		return "", errors.ErrNoParkingLot
 	}
+	if cmd.Command == schema.CMDStatusJSONL {
+		return pl.jsonLines()
+	}
+	slots := ParkingLot.Slots
+	if len(cmd.Arguments) > 0 {
+		floor, err := strconv.Atoi(cmd.Arguments[0])
+		if err != nil || floor <= 0 {
+			return "", errors.ErrInvalidFloor
+		}
+		slots = ParkingLot.GetSlotsByFloor(floor)
+		if len(slots) == 0 {
+			return "", errors.ErrInvalidFloor
+		}
+	}
 	var slotStatus = []string{fmt.Sprintf("%-10s%-20s%-10s", "Slot No.", "Registration No", "Colour")}
-	for _, slot := range ParkingLot.Slots {
+	for _, slot := range slots {
 		if slot.IsFree {
 			slotStatus = append(slotStatus, fmt.Sprintf("%-10d%-20s%-10s", slot.GetID(), "Slot is free", ""))
 		} else {
@@ -35,3 +52,20 @@ func (pl *statusStore) Execute(cmd *schema.Command) (string, error) {
 	}
 	return strings.Join(slotStatus, utils.NewLineDelim), nil
 }
+
+// jsonLines returns one JSON object per occupied slot, newline delimited,
+// an empty string when no slot is occupied
+func (pl *statusStore) jsonLines() (string, error) {
+	var lines []string
+	for _, slot := range ParkingLot.Slots {
+		if !slot.IsSlotOccupied() {
+			continue
+		}
+		line, err := json.Marshal(slot)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, string(line))
+	}
+	return strings.Join(lines, utils.NewLineDelim), nil
+}
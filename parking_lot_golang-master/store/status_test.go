@@ -1,7 +1,9 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"parking_lot/errors"
 	"parking_lot/schema"
@@ -61,4 +63,107 @@ var _ = Describe("parking lot store tests", func() {
 			Expect(res).To(Equal(res))
 		})
 	})
+
+	Context("status by floor", func() {
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("Create a parking lot with 4 slots on 2 floors", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"4", "2", "1"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 4)))
+		})
+
+		It("Get Status of floor 2", func() {
+			cmd := &schema.Command{
+				Command:   "status",
+				Arguments: []string{"2"},
+			}
+			res, err := connection.Status().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(res, "\n")
+			Expect(len(lines)).To(Equal(3))
+			Expect(lines[1]).To(HavePrefix("3 "))
+			Expect(lines[2]).To(HavePrefix("4 "))
+		})
+
+		It("Get Status of an invalid floor", func() {
+			for _, floor := range []string{"3", "0", "first"} {
+				cmd := &schema.Command{
+					Command:   "status",
+					Arguments: []string{floor},
+				}
+				res, err := connection.Status().Execute(cmd)
+				Expect(err).To(Equal(errors.ErrInvalidFloor))
+				Expect(res).To(Equal(""))
+			}
+		})
+	})
+
+	Context("status as json lines", func() {
+		It("Tear Down Store Data", func() {
+			TearDown()
+		})
+
+		It("Create a parking lot with 3 slots", func() {
+			cmd := &schema.Command{
+				Command:   "create_parking_lot",
+				Arguments: []string{"3"},
+			}
+			res, err := connection.CreateParkingLot().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(fmt.Sprintf(ParkinglotCreatedInfo, 3)))
+		})
+
+		It("Get nothing for an empty lot", func() {
+			cmd := &schema.Command{
+				Command: schema.CMDStatusJSONL,
+			}
+			Ω(cmd.Ok()).ShouldNot(HaveOccurred())
+			res, err := connection.Status().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal(""))
+		})
+
+		It("park two vehicles", func() {
+			for _, args := range [][]string{{"KA-01-HH-1234", "White"}, {"KA-01-HH-9999", "Red"}} {
+				cmd := &schema.Command{
+					Command:   "park",
+					Arguments: args,
+				}
+				_, err := connection.Park().Execute(cmd)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+		})
+
+		It("Get a json line per occupied slot", func() {
+			cmd := &schema.Command{
+				Command: schema.CMDStatusJSONL,
+			}
+			res, err := connection.Status().Execute(cmd)
+			Ω(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(res, "\n")
+			Expect(len(lines)).To(Equal(2))
+
+			expected := []struct {
+				id     uint
+				regNum string
+				colour string
+			}{{1, "KA-01-HH-1234", "white"}, {2, "KA-01-HH-9999", "red"}}
+			for i, line := range lines {
+				var slot schema.Slot
+				Ω(json.Unmarshal([]byte(line), &slot)).ShouldNot(HaveOccurred())
+				Expect(slot.ID).To(Equal(expected[i].id))
+				Expect(slot.IsFree).To(BeFalse())
+				Expect(slot.Vehicle).NotTo(BeNil())
+				Expect(slot.Vehicle.RegistrationNumber).To(Equal(expected[i].regNum))
+				Expect(slot.Vehicle.Colour).To(Equal(expected[i].colour))
+			}
+		})
+	})
 })